@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/api"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/media"
+
+	"github.com/google/uuid"
+)
+
+// importMedia stores image files as media of a user, with the same
+// processing as uploads, and prints the ID of each new media.
+func importMedia(cfg *api.ApiConfig, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: import-media <user-id> <file>...")
+	}
+	userID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	for _, path := range args[1:] {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.Size() > int64(cfg.MediaPool.Options().MaxSize) {
+			return fmt.Errorf("%s: %w", path, media.ErrTooLarge)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		m, err := cfg.StoreMedia(context.Background(), userID, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Printf("%s\t%s\n", path, m.ID)
+	}
+	return nil
+}
+
+// promoteAdmin makes the user with the given email the first admin. Once
+// there is an admin, further roles are given through the admin API.
+func promoteAdmin(cfg *api.ApiConfig, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: promote-admin <email>")
+	}
+	promoted, err := cfg.PromoteFirstAdmin(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	if !promoted {
+		return errors.New("no such user, or there already is an admin")
+	}
+	fmt.Printf("%s is now an admin\n", args[0])
+	return nil
+}
@@ -0,0 +1,693 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxReasonLength = 1000
+	// passwordResetTTL is how long a user has to pick a new password after
+	// an admin forced a reset.
+	passwordResetTTL = 72 * time.Hour
+)
+
+// Actions recorded in the audit log.
+const (
+	auditSetRole       = "user.set_role"
+	auditSuspend       = "user.suspend"
+	auditUnsuspend     = "user.unsuspend"
+	auditBan           = "user.ban"
+	auditUnban         = "user.unban"
+	auditPasswordReset = "user.force_password_reset"
+	auditChirpyRed     = "user.set_chirpy_red"
+	auditPromoteAdmin  = "user.promote_first_admin"
+	auditHideHashtag   = "hashtag.hide"
+	auditUnhideHashtag = "hashtag.unhide"
+)
+
+// AdminUser is a user as staff see them, with their standing.
+type AdminUser struct {
+	User
+	SuspendedUntil        *time.Time `json:"suspended_until"`
+	BannedAt              *time.Time `json:"banned_at"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+}
+
+func adminUserFromDatabase(dbUser database.User) AdminUser {
+	return AdminUser{
+		User:                  userFromDatabase(dbUser),
+		SuspendedUntil:        nullTimePtr(dbUser.SuspendedUntil),
+		BannedAt:              nullTimePtr(dbUser.BannedAt),
+		PasswordResetRequired: dbUser.PasswordResetRequired,
+	}
+}
+
+type LoginSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+type OAuthSession struct {
+	ClientID   string     `json:"client_id"`
+	ClientName string     `json:"client_name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type AuditEntry struct {
+	ID           uuid.UUID       `json:"id"`
+	ActorID      uuid.NullUUID   `json:"actor_id"`
+	Action       string          `json:"action"`
+	TargetUserID uuid.NullUUID   `json:"target_user_id"`
+	Reason       string          `json:"reason"`
+	Details      json.RawMessage `json:"details"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type actorKey struct{}
+
+// actorFromContext returns the staff member MiddlewareRole let through.
+func actorFromContext(ctx context.Context) database.User {
+	actor, _ := ctx.Value(actorKey{}).(database.User)
+	return actor
+}
+
+// adminTarget loads the user named in the path of an admin endpoint.
+func (cfg *ApiConfig) adminTarget(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+		return database.User{}, false
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return database.User{}, false
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return database.User{}, false
+	}
+	return dbUser, true
+}
+
+// checkOutranks refuses sanctions against oneself and against staff of an
+// equal or higher role.
+func checkOutranks(w http.ResponseWriter, actor, target database.User) bool {
+	if actor.ID == target.ID {
+		respondWithJSON(w, http.StatusForbidden, returnError{Error: "Cannot act on your own account"})
+		return false
+	}
+	if roleAtLeast(target.Role, actor.Role) {
+		respondWithJSON(w, http.StatusForbidden, returnError{Error: "Cannot act on a user with an equal or higher role"})
+		return false
+	}
+	return true
+}
+
+func checkReason(w http.ResponseWriter, reason string) bool {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxReasonLength {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "A reason of up to 1000 characters is required"})
+		return false
+	}
+	return true
+}
+
+// recordAudit adds an entry to the audit log, inside the transaction of the
+// action it records so neither happens without the other. A zero actor is
+// the command line, and a zero target an action not aimed at a user.
+func recordAudit(ctx context.Context, q *database.Queries, actor database.User, action string, target database.User, reason string, details map[string]any) error {
+	dat, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return q.CreateAuditLogEntry(ctx, database.CreateAuditLogEntryParams{
+		ID:           uuid.New(),
+		ActorID:      uuid.NullUUID{UUID: actor.ID, Valid: actor.ID != uuid.Nil},
+		Action:       action,
+		TargetUserID: uuid.NullUUID{UUID: target.ID, Valid: target.ID != uuid.Nil},
+		Reason:       strings.TrimSpace(reason),
+		Details:      dat,
+		CreatedAt:    time.Now(),
+	})
+}
+
+// revokeAllSessions signs a user out everywhere: logins, OAuth clients and
+// API keys.
+func revokeAllSessions(ctx context.Context, q *database.Queries, userID uuid.UUID) error {
+	revokedAt := sql.NullTime{Time: time.Now(), Valid: true}
+	err := q.RevokeRefreshTokensByUser(ctx, database.RevokeRefreshTokensByUserParams{
+		UserID:    userID,
+		RevokedAt: revokedAt,
+	})
+	if err != nil {
+		return err
+	}
+	err = q.RevokeOAuthGrantsByUser(ctx, database.RevokeOAuthGrantsByUserParams{
+		UserID:    userID,
+		RevokedAt: revokedAt,
+	})
+	if err != nil {
+		return err
+	}
+	return q.RevokeAPIKeysByUser(ctx, database.RevokeAPIKeysByUserParams{
+		UserID:    userID,
+		RevokedAt: revokedAt,
+	})
+}
+
+// GetAdminUsers lists users, newest first. The optional q parameter matches
+// part of an email address or handle, and role filters by role.
+func (cfg *ApiConfig) GetAdminUsers(w http.ResponseWriter, r *http.Request) {
+	var query, role sql.NullString
+
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		// Searches are literal, so LIKE wildcards are escaped.
+		q = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q)
+		query = sql.NullString{String: q, Valid: true}
+	}
+	if queryRole := r.URL.Query().Get("role"); queryRole != "" {
+		if !roleAtLeast(queryRole, RoleUser) {
+			log.Printf("Invalid role: %s", queryRole)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid role"})
+			return
+		}
+		role = sql.NullString{String: queryRole, Valid: true}
+	}
+
+	limit, offset, err := parsePagination(r, defaultPageSize)
+	if err != nil {
+		log.Printf("Invalid pagination: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid limit or offset"})
+		return
+	}
+
+	dbUsers, err := cfg.DbQueries.SearchUsers(r.Context(), database.SearchUsersParams{
+		Query:  query,
+		Role:   role,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		log.Printf("Error searching users: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	users := make([]AdminUser, len(dbUsers))
+	for i, dbUser := range dbUsers {
+		users[i] = adminUserFromDatabase(dbUser)
+	}
+	respondWithJSON(w, http.StatusOK, users)
+}
+
+func (cfg *ApiConfig) GetAdminUser(w http.ResponseWriter, r *http.Request) {
+	dbUser, ok := cfg.adminTarget(w, r)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, adminUserFromDatabase(dbUser))
+}
+
+// GetAdminUserSessions lists everything a user is signed in with.
+func (cfg *ApiConfig) GetAdminUserSessions(w http.ResponseWriter, r *http.Request) {
+	type sessions struct {
+		Logins      []LoginSession `json:"logins"`
+		OAuthGrants []OAuthSession `json:"oauth_grants"`
+		APIKeys     []APIKey       `json:"api_keys"`
+	}
+
+	dbUser, ok := cfg.adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	dbTokens, err := cfg.DbQueries.GetRefreshTokensByUser(r.Context(), dbUser.ID)
+	if err != nil {
+		log.Printf("Error getting refresh tokens: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	dbGrants, err := cfg.DbQueries.GetOAuthGrantsByUser(r.Context(), dbUser.ID)
+	if err != nil {
+		log.Printf("Error getting OAuth grants: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	dbKeys, err := cfg.DbQueries.GetAPIKeysByUser(r.Context(), dbUser.ID)
+	if err != nil {
+		log.Printf("Error getting API keys: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	res := sessions{
+		Logins:      make([]LoginSession, len(dbTokens)),
+		OAuthGrants: make([]OAuthSession, len(dbGrants)),
+		APIKeys:     make([]APIKey, len(dbKeys)),
+	}
+	for i, dbToken := range dbTokens {
+		res.Logins[i] = LoginSession{
+			CreatedAt: dbToken.CreatedAt,
+			ExpiresAt: dbToken.ExpiresAt,
+			RevokedAt: nullTimePtr(dbToken.RevokedAt),
+		}
+	}
+	for i, dbGrant := range dbGrants {
+		res.OAuthGrants[i] = OAuthSession{
+			ClientID:   dbGrant.ClientID,
+			ClientName: dbGrant.Name,
+			Scopes:     dbGrant.Scopes,
+			CreatedAt:  dbGrant.CreatedAt,
+			UpdatedAt:  dbGrant.UpdatedAt,
+			ExpiresAt:  dbGrant.ExpiresAt,
+			RevokedAt:  nullTimePtr(dbGrant.RevokedAt),
+		}
+	}
+	for i, dbKey := range dbKeys {
+		res.APIKeys[i] = apiKeyFromDatabase(dbKey)
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// GetAdminUserChirps lists every chirp of a user, including drafts,
+// scheduled chirps and those in the trash, with their content.
+func (cfg *ApiConfig) GetAdminUserChirps(w http.ResponseWriter, r *http.Request) {
+	dbUser, ok := cfg.adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := parsePagination(r, defaultPageSize)
+	if err != nil {
+		log.Printf("Invalid pagination: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid limit or offset"})
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetAllChirpsByUserPage(r.Context(), database.GetAllChirpsByUserPageParams{
+		UserID: dbUser.ID,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		log.Printf("Error getting chirps: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	chirps := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDatabase(dbChirp)
+		// Staff need to see what was deleted, not a tombstone.
+		chirps[i].Body = dbChirp.Body
+	}
+	if err := cfg.loadChirpDetails(r.Context(), chirps, uuid.NullUUID{}); err != nil {
+		log.Printf("Error loading chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// SuspendUser keeps a user from logging in and chirping until a given time.
+func (cfg *ApiConfig) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Until  time.Time `json:"until"`
+		Reason string    `json:"reason"`
+	}
+
+	actor := actorFromContext(r.Context())
+	target, ok := cfg.adminTarget(w, r)
+	if !ok || !checkOutranks(w, actor, target) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !checkReason(w, params.Reason) {
+		return
+	}
+	if !params.Until.After(time.Now()) {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Suspension must end in the future"})
+		return
+	}
+
+	cfg.updateStanding(w, r, actor, target, auditSuspend, params.Reason, map[string]any{"until": params.Until.UTC()},
+		func(q *database.Queries) (database.User, error) {
+			return q.UpdateUserSuspension(r.Context(), database.UpdateUserSuspensionParams{
+				ID:             target.ID,
+				SuspendedUntil: sql.NullTime{Time: params.Until.UTC(), Valid: true},
+				UpdatedAt:      time.Now(),
+			})
+		})
+}
+
+func (cfg *ApiConfig) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	actor := actorFromContext(r.Context())
+	target, ok := cfg.adminTarget(w, r)
+	if !ok || !checkOutranks(w, actor, target) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !checkReason(w, params.Reason) {
+		return
+	}
+
+	cfg.updateStanding(w, r, actor, target, auditUnsuspend, params.Reason, map[string]any{},
+		func(q *database.Queries) (database.User, error) {
+			return q.UpdateUserSuspension(r.Context(), database.UpdateUserSuspensionParams{
+				ID:        target.ID,
+				UpdatedAt: time.Now(),
+			})
+		})
+}
+
+// BanUser keeps a user from logging in and chirping for good, and signs
+// them out everywhere.
+func (cfg *ApiConfig) BanUser(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	actor := actorFromContext(r.Context())
+	target, ok := cfg.adminTarget(w, r)
+	if !ok || !checkOutranks(w, actor, target) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !checkReason(w, params.Reason) {
+		return
+	}
+
+	cfg.updateStanding(w, r, actor, target, auditBan, params.Reason, map[string]any{},
+		func(q *database.Queries) (database.User, error) {
+			if err := revokeAllSessions(r.Context(), q, target.ID); err != nil {
+				return database.User{}, err
+			}
+			return q.UpdateUserBan(r.Context(), database.UpdateUserBanParams{
+				ID:        target.ID,
+				BannedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+				UpdatedAt: time.Now(),
+			})
+		})
+}
+
+func (cfg *ApiConfig) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	actor := actorFromContext(r.Context())
+	target, ok := cfg.adminTarget(w, r)
+	if !ok || !checkOutranks(w, actor, target) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !checkReason(w, params.Reason) {
+		return
+	}
+
+	cfg.updateStanding(w, r, actor, target, auditUnban, params.Reason, map[string]any{},
+		func(q *database.Queries) (database.User, error) {
+			return q.UpdateUserBan(r.Context(), database.UpdateUserBanParams{
+				ID:        target.ID,
+				UpdatedAt: time.Now(),
+			})
+		})
+}
+
+// SetChirpyRed grants or takes away Chirpy Red by hand, for when the
+// payment provider's webhook got it wrong.
+func (cfg *ApiConfig) SetChirpyRed(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		IsChirpyRed *bool  `json:"is_chirpy_red"`
+		Reason      string `json:"reason"`
+	}
+
+	actor := actorFromContext(r.Context())
+	target, ok := cfg.adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if params.IsChirpyRed == nil {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Missing is_chirpy_red"})
+		return
+	}
+	if !checkReason(w, params.Reason) {
+		return
+	}
+
+	details := map[string]any{"is_chirpy_red": *params.IsChirpyRed, "previous": target.IsChirpyRed}
+	cfg.updateStanding(w, r, actor, target, auditChirpyRed, params.Reason, details,
+		func(q *database.Queries) (database.User, error) {
+			return q.UpdateUserRed(r.Context(), database.UpdateUserRedParams{
+				ID:          target.ID,
+				IsChirpyRed: *params.IsChirpyRed,
+				UpdatedAt:   time.Now(),
+			})
+		})
+}
+
+// updateStanding applies an admin action to target and records it in the
+// audit log, in one transaction, then answers with the updated user.
+func (cfg *ApiConfig) updateStanding(w http.ResponseWriter, r *http.Request, actor, target database.User, action, reason string, details map[string]any, update func(q *database.Queries) (database.User, error)) {
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbUser, err := update(qtx)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error applying %s: %s", action, err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := recordAudit(r.Context(), qtx, actor, action, target, reason, details); err != nil {
+		log.Printf("Error recording audit entry: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, adminUserFromDatabase(dbUser))
+}
+
+// ForcePasswordReset signs a user out everywhere and keeps them from
+// logging in until they set a new password with the token mailed to them.
+func (cfg *ApiConfig) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	actor := actorFromContext(r.Context())
+	target, ok := cfg.adminTarget(w, r)
+	if !ok || !checkOutranks(w, actor, target) {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !checkReason(w, params.Reason) {
+		return
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error creating password reset token: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbUser, err := qtx.RequirePasswordReset(r.Context(), database.RequirePasswordResetParams{
+		ID:        target.ID,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error requiring password reset: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err := revokeAllSessions(r.Context(), qtx, target.ID); err != nil {
+		log.Printf("Error revoking sessions: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	err = qtx.UpsertPasswordReset(r.Context(), database.UpsertPasswordResetParams{
+		UserID:    target.ID,
+		TokenHash: auth.HashToken(token),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		log.Printf("Error creating password reset: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err := recordAudit(r.Context(), qtx, actor, auditPasswordReset, target, params.Reason, map[string]any{}); err != nil {
+		log.Printf("Error recording audit entry: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	// The reset stands even if the mail fails; another reset sends a new
+	// token.
+	body := fmt.Sprintf("An administrator has asked you to choose a new password for your Chirpy account. Use this token to set it:\n\n%s\n\nIt expires in 72 hours.\n", token)
+	if err := cfg.Mailer.Send(r.Context(), dbUser.Email, "Reset your Chirpy password", body); err != nil {
+		log.Printf("Error sending password reset email: %s", err)
+	}
+
+	respondWithJSON(w, http.StatusOK, adminUserFromDatabase(dbUser))
+}
+
+// GetAuditLog lists admin actions, newest first, optionally filtered by
+// actor_id, target_user_id and action.
+func (cfg *ApiConfig) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	params := database.GetAuditLogParams{}
+
+	for name, dest := range map[string]*uuid.NullUUID{"actor_id": &params.ActorID, "target_user_id": &params.TargetUserID} {
+		if v := r.URL.Query().Get(name); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				log.Printf("Invalid %s: %s", name, err)
+				respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid " + name})
+				return
+			}
+			*dest = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	}
+	if action := r.URL.Query().Get("action"); action != "" {
+		params.Action = sql.NullString{String: action, Valid: true}
+	}
+
+	limit, offset, err := parsePagination(r, defaultPageSize)
+	if err != nil {
+		log.Printf("Invalid pagination: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid limit or offset"})
+		return
+	}
+	params.Limit = int32(limit)
+	params.Offset = int32(offset)
+
+	dbEntries, err := cfg.DbQueries.GetAuditLog(r.Context(), params)
+	if err != nil {
+		log.Printf("Error getting audit log: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	entries := make([]AuditEntry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		entries[i] = AuditEntry{
+			ID:           dbEntry.ID,
+			ActorID:      dbEntry.ActorID,
+			Action:       dbEntry.Action,
+			TargetUserID: dbEntry.TargetUserID,
+			Reason:       dbEntry.Reason,
+			Details:      dbEntry.Details,
+			CreatedAt:    dbEntry.CreatedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, entries)
+}
@@ -1,24 +1,160 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/blobstore"
 	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/mail"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/media"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/unfurl"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/webauthn"
+
+	"github.com/google/uuid"
 )
 
 type ApiConfig struct {
 	JwtSecret      string
 	PolkaApiKey    string
 	FileserverHits int
+	DB             *sql.DB
 	DbQueries      *database.Queries
+	Broker         *pubsub.Broker
+	Blobs          blobstore.BlobStore
+	MediaPool      *media.Pool
+	Unfurler       unfurl.Fetcher
+	Mailer         mail.Mailer
+	// Hasher hashes new passwords. Stored hashes it would not produce are
+	// upgraded on login.
+	Hasher auth.PasswordHasher
+	// Breaches refuses new passwords known from data breaches when set.
+	Breaches auth.BreachChecker
+	WebAuthn *webauthn.RelyingParty
+	// Platform is "dev" on developer machines, which enables destructive
+	// endpoints such as /admin/reset.
+	Platform string
+	// TrashRetention is how long deleted chirps and users can be restored
+	// before they are purged.
+	TrashRetention time.Duration
+	// WsOrigins lists the browser origins allowed to open WebSockets.
+	// Requests without an Origin header do not come from browsers and are
+	// let through.
+	WsOrigins []string
+
+	wsMu    sync.Mutex
+	wsConns map[uuid.UUID]int
 }
 
+var errGrantRevoked = errors.New("grant was revoked")
+
 type returnError struct {
 	Error string `json:"error"`
 }
 
+// caller is who a request acts for. Chirpy's own app may do anything;
+// OAuth clients and personal API keys are limited to their scopes.
+type caller struct {
+	userID     uuid.UUID
+	user       database.User
+	firstParty bool
+	scopes     []string
+}
+
+func (c caller) allows(scope string) bool {
+	return c.firstParty || slices.Contains(c.scopes, scope)
+}
+
+// identify reads the caller from an access token in the Bearer scheme or a
+// personal API key in the ApiKey scheme.
+func (cfg *ApiConfig) identify(r *http.Request) (caller, error) {
+	if key, err := auth.GetAPIKey(r.Header); err == nil {
+		return cfg.identifyAPIKey(r.Context(), key)
+	}
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return caller{}, err
+	}
+	return cfg.identifyToken(r.Context(), token)
+}
+
+// identifyToken reads the caller from an access token. The user is looked
+// up too, so tokens stop working as soon as their account is deleted, and
+// authorize can check their standing.
+func (cfg *ApiConfig) identifyToken(ctx context.Context, token string) (caller, error) {
+	accessToken, err := cfg.parseAccessToken(ctx, token)
+	if err != nil {
+		return caller{}, err
+	}
+	dbUser, err := cfg.DbQueries.GetUserByID(ctx, accessToken.UserID)
+	if err != nil {
+		return caller{}, err
+	}
+	return caller{
+		userID:     accessToken.UserID,
+		user:       dbUser,
+		firstParty: accessToken.ClientID == "",
+		scopes:     accessToken.Scopes,
+	}, nil
+}
+
+// authenticate returns the user the request acts for, and answers 401 or
+// 403 itself otherwise. OAuth clients and API keys must carry scope; an
+// empty scope keeps the endpoint to Chirpy's own app. Banned and suspended
+// users are refused, as are those who must reset their password, so
+// credentials issued before a sanction stop working at once.
+func (cfg *ApiConfig) authenticate(w http.ResponseWriter, r *http.Request, scope string) (uuid.UUID, bool) {
+	c, err := cfg.identify(r)
+	return authorize(w, c, err, scope)
+}
+
+// authorize answers for authenticate once the caller has been identified,
+// or failed to be.
+func authorize(w http.ResponseWriter, c caller, err error, scope string) (uuid.UUID, bool) {
+	if err != nil {
+		log.Printf("Error authenticating request: %s", err)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return uuid.Nil, false
+	}
+	if !c.allows(scope) {
+		log.Printf("Credentials of user %s are missing scope %q", c.userID, scope)
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+		respondWithJSON(w, http.StatusForbidden, returnError{Error: "Insufficient scope"})
+		return uuid.Nil, false
+	}
+	if !checkStanding(w, c.user) {
+		return uuid.Nil, false
+	}
+	return c.userID, true
+}
+
+// parseAccessToken validates an access token. Tokens issued to OAuth
+// clients are also checked against their grant, which may be revoked.
+func (cfg *ApiConfig) parseAccessToken(ctx context.Context, token string) (auth.AccessToken, error) {
+	accessToken, err := auth.ParseAccessToken(token, cfg.JwtSecret)
+	if err != nil || accessToken.ClientID == "" {
+		return accessToken, err
+	}
+	grant, err := cfg.DbQueries.GetOAuthGrant(ctx, accessToken.GrantID)
+	if err != nil {
+		return auth.AccessToken{}, err
+	}
+	if grant.RevokedAt.Valid {
+		return auth.AccessToken{}, errGrantRevoked
+	}
+	return accessToken, nil
+}
+
 func respondWithError(w http.ResponseWriter, code int, msg string) {
 	dat, err := json.Marshal(returnError{Error: msg})
 	if err != nil {
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// newTestConfig connects to the database named by TEST_DB_URL, which must be
+// migrated up to date. Tests that need it are skipped without one.
+func newTestConfig(t *testing.T) *ApiConfig {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf(`sql.Open returned an error: %v`, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &ApiConfig{
+		JwtSecret:      "test secret",
+		DB:             db,
+		DbQueries:      database.New(db),
+		TrashRetention: 30 * 24 * time.Hour,
+	}
+}
+
+// createTestUser adds a user with a unique email address.
+func createTestUser(t *testing.T, cfg *ApiConfig) database.User {
+	t.Helper()
+	dbUser, err := cfg.DbQueries.CreateUser(context.Background(), database.CreateUserParams{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		Email:          uuid.NewString() + "@example.com",
+		HashedPassword: "unused",
+	})
+	if err != nil {
+		t.Fatalf(`CreateUser returned an error: %v`, err)
+	}
+	return dbUser
+}
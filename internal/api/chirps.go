@@ -1,25 +1,55 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"sort"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
 	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/unfurl"
 
 	"github.com/google/uuid"
 )
 
+const (
+	chirpPublished = "published"
+	chirpScheduled = "scheduled"
+)
+
+// Chirp is a chirp as the API shows it. UserID is null on tombstones whose
+// author was purged.
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID           uuid.UUID      `json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Body         string         `json:"body"`
+	UserID       uuid.NullUUID  `json:"user_id"`
+	Entities     *ChirpEntities `json:"entities,omitempty"`
+	LikeCount    int32          `json:"like_count"`
+	LikedByMe    *bool          `json:"liked_by_me,omitempty"`
+	InReplyTo    uuid.NullUUID  `json:"in_reply_to"`
+	RootID       uuid.NullUUID  `json:"root_id"`
+	Deleted      bool           `json:"deleted,omitempty"`
+	RechirpOf    uuid.NullUUID  `json:"rechirp_of"`
+	Rechirped    *Chirp         `json:"rechirped_chirp,omitempty"`
+	QuoteOf      uuid.NullUUID  `json:"quote_of"`
+	Quoted       *Chirp         `json:"quoted_chirp,omitempty"`
+	RechirpCount int32          `json:"rechirp_count"`
+	Media        []Media        `json:"media,omitempty"`
+	Preview      *LinkPreview   `json:"preview,omitempty"`
+	Status       string         `json:"status"`
+	PublishAt    *time.Time     `json:"publish_at,omitempty"`
+	PublishedAt  *time.Time     `json:"published_at,omitempty"`
+
+	previewURL string
 }
 
 func cleanMessage(msg string) string {
@@ -37,61 +67,264 @@ func cleanMessage(msg string) string {
 	return strings.Join(clean, " ")
 }
 
-func (cfg *ApiConfig) CreateChirp(w http.ResponseWriter, r *http.Request) {
-	type paramRequest struct {
-		Body string `json:"body"`
+// publishChirp makes a new chirp visible: its hashtags and mentions are
+// indexed and subscribers are notified.
+func publishChirp(ctx context.Context, q *database.Queries, dbChirp database.Chirp) error {
+	err := indexChirpEntities(ctx, q, dbChirp)
+	if err != nil {
+		return err
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	_, err = q.CreateChirpEvent(ctx, database.CreateChirpEventParams{
+		CreatedAt: dbChirp.PublishedAt.Time,
+		EventType: pubsub.ChirpCreated,
+		ChirpID:   dbChirp.ID,
+		UserID:    dbChirp.UserID.UUID,
+		Body:      dbChirp.Body,
+	})
+	return err
+}
+
+func chirpFromDatabase(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:           dbChirp.ID,
+		CreatedAt:    dbChirp.CreatedAt,
+		UpdatedAt:    dbChirp.UpdatedAt,
+		Body:         dbChirp.Body,
+		UserID:       dbChirp.UserID,
+		LikeCount:    dbChirp.LikeCount,
+		InReplyTo:    dbChirp.InReplyTo,
+		RootID:       dbChirp.RootID,
+		Deleted:      dbChirp.DeletedAt.Valid,
+		RechirpOf:    dbChirp.RechirpOf,
+		QuoteOf:      dbChirp.QuoteOf,
+		RechirpCount: dbChirp.RechirpCount,
+		Status:       dbChirp.Status,
+		PublishedAt:  nullTimePtr(dbChirp.PublishedAt),
+		previewURL:   dbChirp.PreviewUrl.String,
+	}
+	if dbChirp.PublishAt.Valid {
+		chirp.PublishAt = &dbChirp.PublishAt.Time
+	}
+	// Deleted chirps keep their content until purged, but only show up
+	// as tombstones.
+	if chirp.Deleted {
+		chirp.Body = ""
+		chirp.previewURL = ""
+	}
+	return chirp
+}
+
+// loadChirpDetails fills in everything about the chirps that is not stored
+// on the chirps table itself, as seen by the viewer.
+func (cfg *ApiConfig) loadChirpDetails(ctx context.Context, chirps []Chirp, viewerID uuid.NullUUID) error {
+	if err := cfg.loadEmbeddedChirps(ctx, chirps, viewerID); err != nil {
+		return err
+	}
+	return cfg.loadOwnDetails(ctx, chirps, viewerID)
+}
+
+// loadOwnDetails is loadChirpDetails without the embedded chirps.
+func (cfg *ApiConfig) loadOwnDetails(ctx context.Context, chirps []Chirp, viewerID uuid.NullUUID) error {
+	if err := cfg.loadChirpEntities(ctx, chirps); err != nil {
+		return err
+	}
+	if err := cfg.loadChirpMedia(ctx, chirps); err != nil {
+		return err
+	}
+	if err := cfg.loadLinkPreviews(ctx, chirps); err != nil {
+		return err
+	}
+	return cfg.loadLikedByMe(ctx, chirps, viewerID)
+}
+
+func (cfg *ApiConfig) chirpsFromDatabase(ctx context.Context, dbChirps []database.Chirp, viewerID uuid.NullUUID) ([]Chirp, error) {
+	chirps := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = chirpFromDatabase(dbChirp)
+	}
+	if err := cfg.loadChirpDetails(ctx, chirps, viewerID); err != nil {
+		return nil, err
+	}
+	return chirps, nil
+}
+
+// chirpRequest holds what a user submits to create a chirp.
+type chirpRequest struct {
+	Body      string      `json:"body"`
+	InReplyTo *uuid.UUID  `json:"in_reply_to"`
+	QuoteOf   *uuid.UUID  `json:"quote_of"`
+	MediaIDs  []uuid.UUID `json:"media_ids"`
+	PublishAt *time.Time  `json:"publish_at"`
+}
+
+// createChirp validates and cleans a chirp request and stores the chirp
+// with q. Every way of creating a chirp goes through here. On failure the
+// response has already been written and false is returned.
+func (cfg *ApiConfig) createChirp(ctx context.Context, w http.ResponseWriter, q *database.Queries, userID uuid.UUID, params chirpRequest) (database.Chirp, bool) {
+	if len(params.Body) > 140 {
+		log.Printf("Chirp is too long: %d characters", len(params.Body))
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Chirp is too long"})
+		return database.Chirp{}, false
+	}
+
+	err := cfg.checkChirpMedia(ctx, userID, params.MediaIDs)
 	if err != nil {
-		log.Printf("Error getting bearer token: %s", err)
-		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
-		return
+		if errors.Is(err, errInvalidMedia) {
+			log.Printf("Invalid media_ids: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid media_ids"})
+			return database.Chirp{}, false
+		}
+		log.Printf("Error checking media: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return database.Chirp{}, false
+	}
+
+	// Replies belong to the thread of their parent; the root of a thread
+	// has no root_id itself.
+	var inReplyTo, rootID uuid.NullUUID
+	if params.InReplyTo != nil {
+		dbParent, err := cfg.originalChirp(ctx, *params.InReplyTo)
+		if err != nil {
+			if err.Error() == "sql: no rows in result set" {
+				log.Printf("Parent chirp not found: %s", err)
+				respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Parent chirp not found"})
+				return database.Chirp{}, false
+			}
+			log.Printf("Error getting parent chirp: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return database.Chirp{}, false
+		}
+		inReplyTo = uuid.NullUUID{UUID: dbParent.ID, Valid: true}
+		rootID = dbParent.RootID
+		if !rootID.Valid {
+			rootID = inReplyTo
+		}
+	}
+
+	var quoteOf uuid.NullUUID
+	if params.QuoteOf != nil {
+		dbQuoted, err := cfg.originalChirp(ctx, *params.QuoteOf)
+		if err != nil {
+			if err.Error() == "sql: no rows in result set" {
+				log.Printf("Quoted chirp not found: %s", err)
+				respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Quoted chirp not found"})
+				return database.Chirp{}, false
+			}
+			log.Printf("Error getting quoted chirp: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return database.Chirp{}, false
+		}
+		quoteOf = uuid.NullUUID{UUID: dbQuoted.ID, Valid: true}
+	}
+
+	// The first link gets a preview once the unfurler has fetched it.
+	body := cleanMessage(params.Body)
+	var previewURL sql.NullString
+	if urls := unfurl.ExtractURLs(body); len(urls) > 0 {
+		previewURL = sql.NullString{String: urls[0], Valid: true}
+	}
+
+	status := chirpPublished
+	var publishAt sql.NullTime
+	if params.PublishAt != nil {
+		if !params.PublishAt.After(time.Now()) {
+			log.Printf("publish_at is not in the future: %s", params.PublishAt)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "publish_at must be in the future"})
+			return database.Chirp{}, false
+		}
+		status = chirpScheduled
+		publishAt = sql.NullTime{Time: params.PublishAt.Local(), Valid: true}
 	}
-	userID, err := auth.ValidateJWT(token, cfg.JwtSecret)
+
+	dbChirp, err := q.CreateChirp(ctx, database.CreateChirpParams{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+		Body:       body,
+		UserID:     userID,
+		InReplyTo:  inReplyTo,
+		RootID:     rootID,
+		QuoteOf:    quoteOf,
+		PreviewUrl: previewURL,
+		Status:     status,
+		PublishAt:  publishAt,
+	})
 	if err != nil {
-		log.Printf("Error validating token: %s", err)
-		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		log.Printf("Error creating chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return database.Chirp{}, false
+	}
+
+	for i, mediaID := range params.MediaIDs {
+		err = q.CreateChirpAttachment(ctx, database.CreateChirpAttachmentParams{
+			ChirpID:  dbChirp.ID,
+			MediaID:  mediaID,
+			Position: int32(i),
+		})
+		if err != nil {
+			log.Printf("Error attaching media: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return database.Chirp{}, false
+		}
+	}
+
+	if dbChirp.Status == chirpPublished {
+		err = publishChirp(ctx, q, dbChirp)
+		if err != nil {
+			log.Printf("Error publishing chirp: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return database.Chirp{}, false
+		}
+	}
+
+	return dbChirp, true
+}
+
+func (cfg *ApiConfig) CreateChirp(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
 		return
 	}
 
 	decoder := json.NewDecoder(r.Body)
-	params := paramRequest{}
-	err = decoder.Decode(&params)
+	params := chirpRequest{}
+	err := decoder.Decode(&params)
 	if err != nil {
 		log.Printf("Invalid JSON: %s", err)
 		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
 		return
 	}
 
-	if len(params.Body) > 140 {
-		log.Printf("Chirp is too long: %d characters", len(params.Body))
-		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Chirp is too long"})
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
 		return
 	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
 
-	dbChirp, err := cfg.DbQueries.CreateChirp(r.Context(), database.CreateChirpParams{
-		ID:        uuid.New(),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Body:      cleanMessage(params.Body),
-		UserID:    userID,
-	})
+	dbChirp, ok := cfg.createChirp(r.Context(), w, qtx, userID, params)
+	if !ok {
+		return
+	}
+
+	err = tx.Commit()
 	if err != nil {
-		log.Printf("Error creating chirp: %s", err)
+		log.Printf("Error committing transaction: %s", err)
 		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
 		return
 	}
 
-	resChirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), []database.Chirp{dbChirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, resChirp)
+	respondWithJSON(w, http.StatusCreated, resChirps[0])
 }
 
 func (cfg *ApiConfig) GetChirps(w http.ResponseWriter, r *http.Request) {
@@ -109,6 +342,13 @@ func (cfg *ApiConfig) GetChirps(w http.ResponseWriter, r *http.Request) {
 		userID.Valid = true
 	}
 
+	limit, offset, err := parsePagination(r, 0)
+	if err != nil {
+		log.Printf("Invalid pagination: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid limit or offset"})
+		return
+	}
+
 	if userID.Valid {
 		dbChirps, err = cfg.DbQueries.GetChirpsByUser(r.Context(), userID.UUID)
 		if err != nil {
@@ -127,20 +367,20 @@ func (cfg *ApiConfig) GetChirps(w http.ResponseWriter, r *http.Request) {
 
 	resChirps := make([]Chirp, len(dbChirps))
 	for i, dbChirp := range dbChirps {
-		resChirps[i] = Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			Body:      dbChirp.Body,
-			UserID:    dbChirp.UserID,
-		}
+		resChirps[i] = chirpFromDatabase(dbChirp)
 	}
 
 	querySort := r.URL.Query().Get("sort")
 	if querySort == "desc" {
-		sort.Slice(resChirps, func(i, j int) bool {
-			return resChirps[i].CreatedAt.After(resChirps[j].CreatedAt)
-		})
+		slices.Reverse(resChirps)
+	}
+
+	resChirps = paginate(resChirps, limit, offset)
+	err = cfg.loadChirpDetails(r.Context(), resChirps, cfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, resChirps)
@@ -165,14 +405,18 @@ func (cfg *ApiConfig) GetChirp(w http.ResponseWriter, r *http.Request) {
 		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
 		return
 	}
-	resChirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	if dbChirp.DeletedAt.Valid || dbChirp.Status != chirpPublished {
+		log.Printf("Chirp %s is not published", dbChirp.ID)
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Chirp not found"})
+		return
+	}
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), []database.Chirp{dbChirp}, cfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
 	}
-	respondWithJSON(w, http.StatusOK, resChirp)
+	respondWithJSON(w, http.StatusOK, resChirps[0])
 }
 
 func (cfg *ApiConfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
@@ -183,16 +427,8 @@ func (cfg *ApiConfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		log.Printf("Error getting bearer token: %s", err)
-		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.JwtSecret)
-	if err != nil {
-		log.Printf("Error validating token: %s", err)
-		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
 		return
 	}
 
@@ -208,23 +444,44 @@ func (cfg *ApiConfig) DeleteChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if dbChirp.UserID != userID {
+	if dbChirp.DeletedAt.Valid || dbChirp.Status != chirpPublished {
+		log.Printf("Chirp %s is not published", dbChirp.ID)
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Chirp not found"})
+		return
+	}
+
+	if dbChirp.UserID.UUID != userID {
 		log.Printf("User %s is not authorized to delete chirp %s", userID, chirpID)
 		respondWithJSON(w, http.StatusForbidden, returnError{Error: "Forbidden"})
 		return
 	}
 
-	err = cfg.DbQueries.DeleteChirp(r.Context(), dbChirp.ID)
+	// Chirps go to the trash, together with their rechirps, and show up
+	// as tombstones in the conversations around them. Rechirps have
+	// nothing to restore.
+	if dbChirp.RechirpOf.Valid {
+		err = cfg.DbQueries.DeleteChirp(r.Context(), dbChirp.ID)
+	} else {
+		err = cfg.DbQueries.SoftDeleteChirp(r.Context(), database.SoftDeleteChirpParams{
+			DeletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			ID:        dbChirp.ID,
+		})
+	}
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			log.Printf("Chirp not found: %s", err)
-			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Chirp not found"})
-			return
-		}
 		log.Printf("Error deleting chirp: %s", err)
 		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
 		return
 	}
 
+	_, err = cfg.DbQueries.CreateChirpEvent(r.Context(), database.CreateChirpEventParams{
+		CreatedAt: time.Now(),
+		EventType: pubsub.ChirpDeleted,
+		ChirpID:   dbChirp.ID,
+		UserID:    dbChirp.UserID.UUID,
+	})
+	if err != nil {
+		log.Printf("Error creating chirp event: %s", err)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Drafts may grow past the chirp limit while being edited; the limit is
+// enforced when they are published.
+const maxDraftLength = 1000
+
+type Draft struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	UserID    uuid.UUID     `json:"user_id"`
+	Body      string        `json:"body"`
+	InReplyTo uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf   uuid.NullUUID `json:"quote_of"`
+	MediaIDs  []uuid.UUID   `json:"media_ids"`
+	PublishAt *time.Time    `json:"publish_at,omitempty"`
+}
+
+func draftFromDatabase(dbDraft database.Draft) Draft {
+	draft := Draft{
+		ID:        dbDraft.ID,
+		CreatedAt: dbDraft.CreatedAt,
+		UpdatedAt: dbDraft.UpdatedAt,
+		UserID:    dbDraft.UserID,
+		Body:      dbDraft.Body,
+		InReplyTo: dbDraft.InReplyTo,
+		QuoteOf:   dbDraft.QuoteOf,
+		MediaIDs:  dbDraft.MediaIds,
+	}
+	if draft.MediaIDs == nil {
+		draft.MediaIDs = []uuid.UUID{}
+	}
+	if dbDraft.PublishAt.Valid {
+		draft.PublishAt = &dbDraft.PublishAt.Time
+	}
+	return draft
+}
+
+// chirpRequestFromDraft turns a draft back into what its author would have
+// submitted to create the chirp directly.
+func chirpRequestFromDraft(dbDraft database.Draft) chirpRequest {
+	params := chirpRequest{
+		Body:     dbDraft.Body,
+		MediaIDs: dbDraft.MediaIds,
+	}
+	if dbDraft.InReplyTo.Valid {
+		params.InReplyTo = &dbDraft.InReplyTo.UUID
+	}
+	if dbDraft.QuoteOf.Valid {
+		params.QuoteOf = &dbDraft.QuoteOf.UUID
+	}
+	if dbDraft.PublishAt.Valid {
+		params.PublishAt = &dbDraft.PublishAt.Time
+	}
+	return params
+}
+
+// checkDraft validates what can be checked before publishing. Parents and
+// quoted chirps may still go away, so those are only checked on publish.
+// On failure the response has already been written and false is returned.
+func (cfg *ApiConfig) checkDraft(ctx context.Context, w http.ResponseWriter, userID uuid.UUID, params chirpRequest) bool {
+	if len(params.Body) > maxDraftLength {
+		log.Printf("Draft is too long: %d characters", len(params.Body))
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Draft is too long"})
+		return false
+	}
+
+	err := cfg.checkChirpMedia(ctx, userID, params.MediaIDs)
+	if err != nil {
+		if errors.Is(err, errInvalidMedia) {
+			log.Printf("Invalid media_ids: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid media_ids"})
+			return false
+		}
+		log.Printf("Error checking media: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return false
+	}
+	return true
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.Local(), Valid: true}
+}
+
+func (cfg *ApiConfig) CreateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := chirpRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !cfg.checkDraft(r.Context(), w, userID, params) {
+		return
+	}
+
+	dbDraft, err := cfg.DbQueries.CreateDraft(r.Context(), database.CreateDraftParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		Body:      params.Body,
+		InReplyTo: nullUUID(params.InReplyTo),
+		QuoteOf:   nullUUID(params.QuoteOf),
+		MediaIds:  params.MediaIDs,
+		PublishAt: nullTime(params.PublishAt),
+	})
+	if err != nil {
+		log.Printf("Error creating draft: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, draftFromDatabase(dbDraft))
+}
+
+func (cfg *ApiConfig) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsRead)
+	if !ok {
+		return
+	}
+
+	dbDrafts, err := cfg.DbQueries.GetDraftsByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting drafts: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resDrafts := make([]Draft, len(dbDrafts))
+	for i, dbDraft := range dbDrafts {
+		resDrafts[i] = draftFromDatabase(dbDraft)
+	}
+
+	respondWithJSON(w, http.StatusOK, resDrafts)
+}
+
+func (cfg *ApiConfig) GetDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		log.Printf("Invalid draftID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid draft ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsRead)
+	if !ok {
+		return
+	}
+
+	// Drafts of other users are reported as not found.
+	dbDraft, err := cfg.DbQueries.GetDraft(r.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Draft not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Draft not found"})
+			return
+		}
+		log.Printf("Error getting draft: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftFromDatabase(dbDraft))
+}
+
+func (cfg *ApiConfig) UpdateDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		log.Printf("Invalid draftID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid draft ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := chirpRequest{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !cfg.checkDraft(r.Context(), w, userID, params) {
+		return
+	}
+
+	dbDraft, err := cfg.DbQueries.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		ID:        draftID,
+		UserID:    userID,
+		Body:      params.Body,
+		InReplyTo: nullUUID(params.InReplyTo),
+		QuoteOf:   nullUUID(params.QuoteOf),
+		MediaIds:  params.MediaIDs,
+		PublishAt: nullTime(params.PublishAt),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Draft not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Draft not found"})
+			return
+		}
+		log.Printf("Error updating draft: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftFromDatabase(dbDraft))
+}
+
+func (cfg *ApiConfig) DeleteDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		log.Printf("Invalid draftID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid draft ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	_, err = cfg.DbQueries.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Draft not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Draft not found"})
+			return
+		}
+		log.Printf("Error deleting draft: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PublishDraft turns a draft into a chirp, validated like any other new
+// chirp. The draft is deleted in the same transaction, so it is published
+// at most once and survives when validation fails.
+func (cfg *ApiConfig) PublishDraft(w http.ResponseWriter, r *http.Request) {
+	draftID, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		log.Printf("Invalid draftID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid draft ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbDraft, err := qtx.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Draft not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Draft not found"})
+			return
+		}
+		log.Printf("Error deleting draft: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	dbChirp, ok := cfg.createChirp(r.Context(), w, qtx, userID, chirpRequestFromDraft(dbDraft))
+	if !ok {
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), []database.Chirp{dbChirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, resChirps[0])
+}
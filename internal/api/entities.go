@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/entities"
+
+	"github.com/google/uuid"
+)
+
+type ChirpEntities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+type HashtagEntity struct {
+	Tag     string `json:"tag"`
+	Indices [2]int `json:"indices"`
+}
+
+type MentionEntity struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Indices [2]int    `json:"indices"`
+}
+
+// indexChirpEntities stores the hashtags and the mentions of existing users
+// found in the chirp body.
+func indexChirpEntities(ctx context.Context, q *database.Queries, dbChirp database.Chirp) error {
+	parsed := entities.Parse(dbChirp.Body)
+
+	hashtagIDs := make(map[string]uuid.UUID)
+	for _, tag := range parsed.Tags() {
+		dbHashtag, err := q.UpsertHashtag(ctx, database.UpsertHashtagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			Tag:       tag,
+		})
+		if err != nil {
+			return err
+		}
+		hashtagIDs[tag] = dbHashtag.ID
+	}
+	for _, hashtag := range parsed.Hashtags {
+		err := q.CreateChirpHashtag(ctx, database.CreateChirpHashtagParams{
+			ChirpID:    dbChirp.ID,
+			HashtagID:  hashtagIDs[hashtag.Tag],
+			CreatedAt:  dbChirp.PublishedAt.Time,
+			StartIndex: int32(hashtag.Start),
+			EndIndex:   int32(hashtag.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(parsed.Mentions) == 0 {
+		return nil
+	}
+	handles := make([]string, 0)
+	for _, mention := range parsed.Mentions {
+		handles = append(handles, mention.Text)
+	}
+	userIDs := make(map[string]uuid.UUID)
+	if len(handles) > 0 {
+		dbUsers, err := q.GetUsersByHandles(ctx, handles)
+		if err != nil {
+			return err
+		}
+		for _, dbUser := range dbUsers {
+			userIDs[strings.ToLower(dbUser.Handle.String)] = dbUser.ID
+		}
+	}
+	for _, mention := range parsed.Mentions {
+		userID, ok := userIDs[mention.Text]
+		if !ok {
+			continue
+		}
+		err := q.CreateMention(ctx, database.CreateMentionParams{
+			ChirpID:    dbChirp.ID,
+			UserID:     userID,
+			CreatedAt:  dbChirp.PublishedAt.Time,
+			StartIndex: int32(mention.Start),
+			EndIndex:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadChirpEntities fills in the entities of the given chirps with one
+// query per entity type.
+func (cfg *ApiConfig) loadChirpEntities(ctx context.Context, chirps []Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	byID := make(map[uuid.UUID]*ChirpEntities)
+	for i := range chirps {
+		// Tombstones have no body left to point into.
+		if chirps[i].Deleted {
+			continue
+		}
+		chirpIDs = append(chirpIDs, chirps[i].ID)
+		chirps[i].Entities = &ChirpEntities{
+			Hashtags: make([]HashtagEntity, 0),
+			Mentions: make([]MentionEntity, 0),
+		}
+		byID[chirps[i].ID] = chirps[i].Entities
+	}
+
+	dbHashtags, err := cfg.DbQueries.GetHashtagsByChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	for _, dbHashtag := range dbHashtags {
+		e := byID[dbHashtag.ChirpID]
+		e.Hashtags = append(e.Hashtags, HashtagEntity{
+			Tag:     dbHashtag.Tag,
+			Indices: [2]int{int(dbHashtag.StartIndex), int(dbHashtag.EndIndex)},
+		})
+	}
+
+	dbMentions, err := cfg.DbQueries.GetMentionsByChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	for _, dbMention := range dbMentions {
+		e := byID[dbMention.ChirpID]
+		e.Mentions = append(e.Mentions, MentionEntity{
+			UserID:  dbMention.UserID,
+			Indices: [2]int{int(dbMention.StartIndex), int(dbMention.EndIndex)},
+		})
+	}
+
+	return nil
+}
+
+func (cfg *ApiConfig) GetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		log.Printf("Missing tag")
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Missing tag"})
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetChirpsByHashtag(r.Context(), tag)
+	if err != nil {
+		log.Printf("Error getting chirps by hashtag: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), dbChirps, cfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resChirps)
+}
+
+func (cfg *ApiConfig) GetUserMentions(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		log.Printf("Invalid userID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid user ID"})
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetChirpsMentioningUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting chirps mentioning user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), dbChirps, cfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resChirps)
+}
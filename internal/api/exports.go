@@ -0,0 +1,441 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/blobstore"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	exportDone = "done"
+	// Archives can be downloaded for a week, then they are purged.
+	exportRetention = 7 * 24 * time.Hour
+	// Exports running for longer were abandoned by a crashed instance.
+	exportStaleAfter = 10 * time.Minute
+	exportURL        = "/api/users/export/archive"
+)
+
+type Export struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	URL         string     `json:"url,omitempty"`
+}
+
+func exportFromDatabase(dbExport database.Export) Export {
+	export := Export{
+		ID:        dbExport.ID,
+		CreatedAt: dbExport.CreatedAt,
+		Status:    dbExport.Status,
+		Size:      dbExport.SizeBytes.Int64,
+	}
+	if dbExport.CompletedAt.Valid {
+		export.CompletedAt = &dbExport.CompletedAt.Time
+	}
+	if dbExport.Status == exportDone {
+		export.URL = exportURL
+	}
+	return export
+}
+
+type exportProfile struct {
+	ID          uuid.UUID     `json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Email       string        `json:"email"`
+	Handle      string        `json:"handle,omitempty"`
+	DisplayName string        `json:"display_name"`
+	Bio         string        `json:"bio"`
+	AvatarID    uuid.NullUUID `json:"avatar_media_id"`
+	IsChirpyRed bool          `json:"is_chirpy_red"`
+}
+
+// The archive holds the user's own data as stored, including chirps that
+// are scheduled or in the trash.
+type exportChirp struct {
+	ID        uuid.UUID     `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Body      string        `json:"body"`
+	InReplyTo uuid.NullUUID `json:"in_reply_to"`
+	QuoteOf   uuid.NullUUID `json:"quote_of"`
+	RechirpOf uuid.NullUUID `json:"rechirp_of"`
+	Status    string        `json:"status"`
+	PublishAt *time.Time    `json:"publish_at,omitempty"`
+	DeletedAt *time.Time    `json:"deleted_at,omitempty"`
+}
+
+type exportLike struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Sessions are listed without their tokens.
+type exportSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	dat, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(dat)
+	return err
+}
+
+// writeExportArchive writes a ZIP archive with everything stored about the
+// user: profile.json, chirps.json, drafts.json, likes.json, sessions.json,
+// media.json and the uploaded files under media/.
+func (cfg *ApiConfig) writeExportArchive(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	dbUser, err := cfg.DbQueries.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = writeZipJSON(zw, "profile.json", exportProfile{
+		ID:          dbUser.ID,
+		CreatedAt:   dbUser.CreatedAt,
+		UpdatedAt:   dbUser.UpdatedAt,
+		Email:       dbUser.Email,
+		Handle:      dbUser.Handle.String,
+		DisplayName: dbUser.DisplayName,
+		Bio:         dbUser.Bio,
+		AvatarID:    dbUser.AvatarMediaID,
+		IsChirpyRed: dbUser.IsChirpyRed,
+	})
+	if err != nil {
+		return err
+	}
+
+	dbChirps, err := cfg.DbQueries.GetAllChirpsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	chirps := make([]exportChirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		chirps[i] = exportChirp{
+			ID:        dbChirp.ID,
+			CreatedAt: dbChirp.CreatedAt,
+			UpdatedAt: dbChirp.UpdatedAt,
+			Body:      dbChirp.Body,
+			InReplyTo: dbChirp.InReplyTo,
+			QuoteOf:   dbChirp.QuoteOf,
+			RechirpOf: dbChirp.RechirpOf,
+			Status:    dbChirp.Status,
+			PublishAt: nullTimePtr(dbChirp.PublishAt),
+			DeletedAt: nullTimePtr(dbChirp.DeletedAt),
+		}
+	}
+	if err := writeZipJSON(zw, "chirps.json", chirps); err != nil {
+		return err
+	}
+
+	dbDrafts, err := cfg.DbQueries.GetDraftsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	drafts := make([]Draft, len(dbDrafts))
+	for i, dbDraft := range dbDrafts {
+		drafts[i] = draftFromDatabase(dbDraft)
+	}
+	if err := writeZipJSON(zw, "drafts.json", drafts); err != nil {
+		return err
+	}
+
+	dbLikes, err := cfg.DbQueries.GetLikesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	likes := make([]exportLike, len(dbLikes))
+	for i, dbLike := range dbLikes {
+		likes[i] = exportLike{ChirpID: dbLike.ChirpID, CreatedAt: dbLike.CreatedAt}
+	}
+	if err := writeZipJSON(zw, "likes.json", likes); err != nil {
+		return err
+	}
+
+	dbTokens, err := cfg.DbQueries.GetRefreshTokensByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	sessions := make([]exportSession, len(dbTokens))
+	for i, dbToken := range dbTokens {
+		sessions[i] = exportSession{
+			CreatedAt: dbToken.CreatedAt,
+			ExpiresAt: dbToken.ExpiresAt,
+			RevokedAt: nullTimePtr(dbToken.RevokedAt),
+		}
+	}
+	if err := writeZipJSON(zw, "sessions.json", sessions); err != nil {
+		return err
+	}
+
+	dbMedia, err := cfg.DbQueries.GetMediaFilesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	media := make([]Media, len(dbMedia))
+	for i, m := range dbMedia {
+		media[i] = mediaFromDatabase(m)
+		media[i].URL = "media/" + m.ID.String()
+	}
+	if err := writeZipJSON(zw, "media.json", media); err != nil {
+		return err
+	}
+	for _, m := range dbMedia {
+		err := cfg.copyBlobToZip(ctx, zw, "media/"+m.ID.String(), m.StorageKey)
+		if errors.Is(err, blobstore.ErrNotFound) {
+			log.Printf("Media blob %s of export is missing", m.StorageKey)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func (cfg *ApiConfig) copyBlobToZip(ctx context.Context, zw *zip.Writer, name, key string) error {
+	blob, err := cfg.Blobs.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	// Images are compressed already.
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, blob)
+	return err
+}
+
+// storeExport builds the archive in a temporary file and stores it under key.
+func (cfg *ApiConfig) storeExport(ctx context.Context, userID uuid.UUID, key string) (int64, error) {
+	f, err := os.CreateTemp("", "chirpy-export-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := cfg.writeExportArchive(ctx, userID, f); err != nil {
+		return 0, err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if err := cfg.Blobs.Put(ctx, key, f, size, "application/zip"); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// runNextExport builds the oldest pending export, if any, and reports
+// whether there was one.
+func (cfg *ApiConfig) runNextExport(ctx context.Context) (bool, error) {
+	now := time.Now()
+	dbExport, err := cfg.DbQueries.ClaimExport(ctx, database.ClaimExportParams{
+		StartedAt:   now,
+		StaleBefore: now.Add(-exportStaleAfter),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	key := "exports/" + dbExport.ID.String() + ".zip"
+	size, err := cfg.storeExport(ctx, dbExport.UserID, key)
+	if err != nil {
+		log.Printf("Error building export %s: %s", dbExport.ID, err)
+		err = cfg.DbQueries.FailExport(ctx, database.FailExportParams{
+			ID:          dbExport.ID,
+			CompletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		return true, err
+	}
+
+	err = cfg.DbQueries.CompleteExport(ctx, database.CompleteExportParams{
+		ID:          dbExport.ID,
+		CompletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		StorageKey:  sql.NullString{String: key, Valid: true},
+		SizeBytes:   sql.NullInt64{Int64: size, Valid: true},
+	})
+	return true, err
+}
+
+// RunExporter builds requested exports until ctx is done.
+func (cfg *ApiConfig) RunExporter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			found, err := cfg.runNextExport(ctx)
+			if err != nil {
+				log.Printf("Error running export: %s", err)
+			}
+			if err != nil || !found {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExports removes expired archives and those of deleted users. An
+// archive that can't be removed is kept for the next run, and the others
+// are still removed.
+func (cfg *ApiConfig) purgeExports(ctx context.Context) error {
+	dbExports, err := cfg.DbQueries.GetExpiredExports(ctx, time.Now().Add(-exportRetention))
+	if err != nil {
+		return err
+	}
+	for _, dbExport := range dbExports {
+		if dbExport.StorageKey.Valid {
+			err := cfg.Blobs.Delete(ctx, dbExport.StorageKey.String)
+			if err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+				log.Printf("Error deleting export archive %s: %s", dbExport.StorageKey.String, err)
+				continue
+			}
+		}
+		if err := cfg.DbQueries.DeleteExport(ctx, dbExport.ID); err != nil {
+			log.Printf("Error deleting export %s: %s", dbExport.ID, err)
+		}
+	}
+	return nil
+}
+
+func (cfg *ApiConfig) CreateExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbExport, err := cfg.DbQueries.CreateExport(r.Context(), database.CreateExportParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User %s already has an export in progress", userID)
+			respondWithJSON(w, http.StatusConflict, returnError{Error: "Export already in progress"})
+			return
+		}
+		log.Printf("Error creating export: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.Header().Set("Location", "/api/users/export")
+	respondWithJSON(w, http.StatusAccepted, exportFromDatabase(dbExport))
+}
+
+// GetExport reports the status of the user's latest export.
+func (cfg *ApiConfig) GetExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbExport, err := cfg.DbQueries.GetLatestExportByUser(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Export not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Export not found"})
+			return
+		}
+		log.Printf("Error getting export: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, exportFromDatabase(dbExport))
+}
+
+// GetExportArchive downloads the user's latest completed export.
+func (cfg *ApiConfig) GetExportArchive(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbExport, err := cfg.DbQueries.GetLatestCompletedExportByUser(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Export not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Export not found"})
+			return
+		}
+		log.Printf("Error getting export: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	blob, err := cfg.Blobs.Get(r.Context(), dbExport.StorageKey.String)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			log.Printf("Export blob not found: %s", dbExport.StorageKey.String)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Export not found"})
+			return
+		}
+		log.Printf("Error reading export: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer blob.Close()
+
+	filename := "chirpy-export-" + dbExport.CreatedAt.Format("2006-01-02") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", strconv.FormatInt(dbExport.SizeBytes.Int64, 10))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("Error writing export: %s", err)
+	}
+}
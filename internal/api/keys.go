@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeyPrefix marks Chirpy keys, which helps secret scanners find
+	// leaked ones.
+	apiKeyPrefix        = "chirpy_"
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	maxAPIKeyNameLength = 100
+	// apiKeyTouchInterval is how stale last_used_at may get, sparing a
+	// write on every request.
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	errAPIKeyRevoked = errors.New("API key was revoked")
+	errAPIKeyExpired = errors.New("API key has expired")
+)
+
+type APIKey struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Prefix string    `json:"prefix"`
+	// Key is only shown when the key is created.
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func apiKeyFromDatabase(dbKey database.ApiKey) APIKey {
+	return APIKey{
+		ID:         dbKey.ID,
+		Name:       dbKey.Name,
+		Prefix:     dbKey.Prefix,
+		Scopes:     dbKey.Scopes,
+		CreatedAt:  dbKey.CreatedAt,
+		ExpiresAt:  nullTimePtr(dbKey.ExpiresAt),
+		LastUsedAt: nullTimePtr(dbKey.LastUsedAt),
+		RevokedAt:  nullTimePtr(dbKey.RevokedAt),
+	}
+}
+
+func (cfg *ApiConfig) identifyAPIKey(ctx context.Context, key string) (caller, error) {
+	dbKey, err := cfg.DbQueries.GetAPIKeyByHash(ctx, auth.HashToken(key))
+	if err != nil {
+		return caller{}, err
+	}
+	if dbKey.RevokedAt.Valid {
+		return caller{}, errAPIKeyRevoked
+	}
+	if dbKey.ExpiresAt.Valid && dbKey.ExpiresAt.Time.Before(time.Now()) {
+		return caller{}, errAPIKeyExpired
+	}
+	dbUser, err := cfg.DbQueries.GetUserByID(ctx, dbKey.UserID)
+	if err != nil {
+		return caller{}, err
+	}
+
+	err = cfg.DbQueries.TouchAPIKey(ctx, database.TouchAPIKeyParams{
+		LastUsedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		ID:          dbKey.ID,
+		StaleBefore: sql.NullTime{Time: time.Now().Add(-apiKeyTouchInterval), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error updating last use of API key %s: %s", dbKey.ID, err)
+	}
+
+	return caller{userID: dbKey.UserID, user: dbUser, scopes: dbKey.Scopes}, nil
+}
+
+// CreateAPIKey issues a personal API key, used with the ApiKey
+// authorization scheme. Like OAuth clients, keys are limited to their
+// scopes and cannot manage the account.
+func (cfg *ApiConfig) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || len(params.Name) > maxAPIKeyNameLength {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid key name"})
+		return
+	}
+	scopes, err := auth.ParseScope(strings.Join(params.Scopes, " "))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid scopes"})
+		return
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Expiry must be in the future"})
+		return
+	}
+
+	secret, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error creating API key: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	key := apiKeyPrefix + secret
+
+	dbKey, err := cfg.DbQueries.CreateAPIKey(r.Context(), database.CreateAPIKeyParams{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      params.Name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   auth.HashToken(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: nullTime(params.ExpiresAt),
+	})
+	if err != nil {
+		log.Printf("Error creating API key: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resKey := apiKeyFromDatabase(dbKey)
+	resKey.Key = key
+	respondWithJSON(w, http.StatusCreated, resKey)
+}
+
+func (cfg *ApiConfig) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbKeys, err := cfg.DbQueries.GetAPIKeysByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting API keys: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	keys := make([]APIKey, len(dbKeys))
+	for i, dbKey := range dbKeys {
+		keys[i] = apiKeyFromDatabase(dbKey)
+	}
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey stops a key from working. Revoked keys stay listed so their
+// last use can still be seen.
+func (cfg *ApiConfig) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	keyID, err := uuid.Parse(r.PathValue("keyID"))
+	if err != nil {
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "API key not found"})
+		return
+	}
+
+	rows, err := cfg.DbQueries.RevokeAPIKey(r.Context(), database.RevokeAPIKeyParams{
+		ID:        keyID,
+		UserID:    userID,
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error revoking API key: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "API key not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
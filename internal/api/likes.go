@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// viewerID returns the authenticated caller, if any. Requests without valid
+// credentials, or whose credentials may not read chirps, are treated as
+// anonymous.
+func (cfg *ApiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	c, err := cfg.identify(r)
+	if err != nil || !c.allows(auth.ScopeChirpsRead) {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: c.userID, Valid: true}
+}
+
+// loadLikedByMe marks the chirps liked by the viewer. Nothing is set for
+// anonymous viewers.
+func (cfg *ApiConfig) loadLikedByMe(ctx context.Context, chirps []Chirp, viewerID uuid.NullUUID) error {
+	if !viewerID.Valid || len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i := range chirps {
+		chirpIDs[i] = chirps[i].ID
+	}
+
+	likedIDs, err := cfg.DbQueries.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return err
+	}
+	liked := make(map[uuid.UUID]bool)
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+
+	for i := range chirps {
+		likedByMe := liked[chirps[i].ID]
+		chirps[i].LikedByMe = &likedByMe
+	}
+	return nil
+}
+
+func (cfg *ApiConfig) setChirpLike(w http.ResponseWriter, r *http.Request, like bool) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("Invalid chirpID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid chirp ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	// Liking a rechirp likes the original.
+	dbChirp, err := cfg.originalChirp(r.Context(), chirpID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Chirp not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Chirp not found"})
+			return
+		}
+		log.Printf("Error getting chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	chirpID = dbChirp.ID
+
+	if like {
+		_, err = cfg.DbQueries.LikeChirp(r.Context(), database.LikeChirpParams{
+			UserID:    userID,
+			ChirpID:   chirpID,
+			CreatedAt: time.Now(),
+		})
+	} else {
+		_, err = cfg.DbQueries.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+	}
+	if err != nil {
+		log.Printf("Error updating like: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	// Read the chirp again to return the updated counter.
+	dbChirp, err = cfg.DbQueries.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		log.Printf("Error getting chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), []database.Chirp{dbChirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resChirps[0])
+}
+
+func (cfg *ApiConfig) LikeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, true)
+}
+
+func (cfg *ApiConfig) UnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpLike(w, r, false)
+}
+
+func (cfg *ApiConfig) GetUserLikes(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		log.Printf("Invalid userID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid user ID"})
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetChirpsLikedByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting chirps liked by user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), dbChirps, cfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resChirps)
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/blobstore"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/media"
+
+	"github.com/google/uuid"
+)
+
+const maxChirpAttachments = 4
+
+var errInvalidMedia = errors.New("invalid media")
+
+type MediaVariant struct {
+	Name   string `json:"name"`
+	Width  int32  `json:"width"`
+	Height int32  `json:"height"`
+	URL    string `json:"url"`
+}
+
+type Media struct {
+	ID          uuid.UUID      `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UserID      uuid.UUID      `json:"user_id"`
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"`
+	Width       int32          `json:"width"`
+	Height      int32          `json:"height"`
+	URL         string         `json:"url"`
+	BlurHash    string         `json:"blurhash"`
+	Variants    []MediaVariant `json:"variants"`
+}
+
+func mediaFromDatabase(dbMedia database.MediaFile) Media {
+	return Media{
+		ID:          dbMedia.ID,
+		CreatedAt:   dbMedia.CreatedAt,
+		UserID:      dbMedia.UserID,
+		ContentType: dbMedia.ContentType,
+		Size:        dbMedia.SizeBytes,
+		Width:       dbMedia.Width,
+		Height:      dbMedia.Height,
+		URL:         "/api/media/" + dbMedia.ID.String(),
+		BlurHash:    dbMedia.Blurhash,
+		Variants:    make([]MediaVariant, 0),
+	}
+}
+
+func mediaVariantURL(mediaID uuid.UUID, name string) string {
+	return "/api/media/" + mediaID.String() + "/" + name
+}
+
+// checkChirpMedia verifies that the user may attach the media to a chirp.
+func (cfg *ApiConfig) checkChirpMedia(ctx context.Context, userID uuid.UUID, mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) > maxChirpAttachments {
+		return fmt.Errorf("%w: at most %d attachments", errInvalidMedia, maxChirpAttachments)
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range mediaIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: duplicate media %s", errInvalidMedia, id)
+		}
+		seen[id] = true
+	}
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	dbMedia, err := cfg.DbQueries.GetMediaFilesByIDs(ctx, mediaIDs)
+	if err != nil {
+		return err
+	}
+	if len(dbMedia) != len(mediaIDs) {
+		return fmt.Errorf("%w: media not found", errInvalidMedia)
+	}
+	for _, m := range dbMedia {
+		if m.UserID != userID {
+			return fmt.Errorf("%w: media %s belongs to another user", errInvalidMedia, m.ID)
+		}
+	}
+	return nil
+}
+
+// loadChirpMedia fills in the attachments of the given chirps.
+func (cfg *ApiConfig) loadChirpMedia(ctx context.Context, chirps []Chirp) error {
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		if !chirp.Deleted {
+			chirpIDs = append(chirpIDs, chirp.ID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+
+	dbAttachments, err := cfg.DbQueries.GetAttachmentsByChirps(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID][]Media)
+	for _, a := range dbAttachments {
+		byID[a.ChirpID] = append(byID[a.ChirpID], mediaFromDatabase(database.MediaFile{
+			ID:          a.ID,
+			CreatedAt:   a.CreatedAt,
+			UserID:      a.UserID,
+			ContentType: a.ContentType,
+			SizeBytes:   a.SizeBytes,
+			Width:       a.Width,
+			Height:      a.Height,
+			StorageKey:  a.StorageKey,
+			Blurhash:    a.Blurhash,
+		}))
+	}
+
+	all := make([]*Media, 0, len(dbAttachments))
+	for i := range chirps {
+		chirps[i].Media = byID[chirps[i].ID]
+		for j := range chirps[i].Media {
+			all = append(all, &chirps[i].Media[j])
+		}
+	}
+	return cfg.loadMediaVariants(ctx, all)
+}
+
+func (cfg *ApiConfig) loadMediaVariants(ctx context.Context, items []*Media) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	mediaIDs := make([]uuid.UUID, len(items))
+	for i, m := range items {
+		mediaIDs[i] = m.ID
+	}
+	dbVariants, err := cfg.DbQueries.GetMediaVariantsByMedia(ctx, mediaIDs)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID][]MediaVariant)
+	for _, v := range dbVariants {
+		byID[v.MediaID] = append(byID[v.MediaID], MediaVariant{
+			Name:   v.Name,
+			Width:  v.Width,
+			Height: v.Height,
+			URL:    mediaVariantURL(v.MediaID, v.Name),
+		})
+	}
+	for _, m := range items {
+		if variants, ok := byID[m.ID]; ok {
+			m.Variants = variants
+		}
+	}
+	return nil
+}
+
+// StoreMedia processes an image and stores it with its thumbnails as media
+// of the user. Invalid images are reported with the errors of the media
+// package.
+func (cfg *ApiConfig) StoreMedia(ctx context.Context, userID uuid.UUID, data []byte) (Media, error) {
+	res, err := cfg.MediaPool.Process(ctx, data)
+	if err != nil {
+		return Media{}, err
+	}
+
+	mediaID := uuid.New()
+	variants := append([]media.Variant{res.Original}, res.Thumbnails...)
+	keys := make([]string, 0, len(variants))
+	stored := false
+	defer func() {
+		if stored {
+			return
+		}
+		for _, key := range keys {
+			if err := cfg.Blobs.Delete(context.Background(), key); err != nil {
+				log.Printf("Error deleting media blob %s: %s", key, err)
+			}
+		}
+	}()
+
+	for _, v := range variants {
+		key := "media/" + mediaID.String()
+		if v.Name != res.Original.Name {
+			key += "-" + v.Name
+		}
+		err = cfg.Blobs.Put(ctx, key, bytes.NewReader(v.Data), int64(len(v.Data)), v.ContentType)
+		if err != nil {
+			return Media{}, err
+		}
+		keys = append(keys, key)
+	}
+
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Media{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbMedia, err := qtx.CreateMediaFile(ctx, database.CreateMediaFileParams{
+		ID:          mediaID,
+		CreatedAt:   time.Now(),
+		UserID:      userID,
+		ContentType: res.Original.ContentType,
+		SizeBytes:   int64(len(res.Original.Data)),
+		Width:       int32(res.Original.Width),
+		Height:      int32(res.Original.Height),
+		StorageKey:  keys[0],
+		Blurhash:    res.BlurHash,
+	})
+	if err != nil {
+		return Media{}, err
+	}
+
+	m := mediaFromDatabase(dbMedia)
+	for i, v := range res.Thumbnails {
+		err = qtx.CreateMediaVariant(ctx, database.CreateMediaVariantParams{
+			MediaID:     mediaID,
+			Name:        v.Name,
+			ContentType: v.ContentType,
+			SizeBytes:   int64(len(v.Data)),
+			Width:       int32(v.Width),
+			Height:      int32(v.Height),
+			StorageKey:  keys[i+1],
+		})
+		if err != nil {
+			return Media{}, err
+		}
+		m.Variants = append(m.Variants, MediaVariant{
+			Name:   v.Name,
+			Width:  int32(v.Width),
+			Height: int32(v.Height),
+			URL:    mediaVariantURL(mediaID, v.Name),
+		})
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Media{}, err
+	}
+	stored = true
+	return m, nil
+}
+
+func (cfg *ApiConfig) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing around the file.
+	maxSize := cfg.MediaPool.Options().MaxSize
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxSize)+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			log.Printf("Upload is too large: %s", err)
+			respondWithJSON(w, http.StatusRequestEntityTooLarge, returnError{Error: "File is too large"})
+			return
+		}
+		log.Printf("Invalid upload: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Missing file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxSize)+1))
+	if err != nil {
+		log.Printf("Error reading upload: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid file"})
+		return
+	}
+
+	resMedia, err := cfg.StoreMedia(r.Context(), userID, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrTooLarge):
+			log.Printf("Upload is too large: %s", err)
+			respondWithJSON(w, http.StatusRequestEntityTooLarge, returnError{Error: "File is too large"})
+		case errors.Is(err, media.ErrUnsupportedFormat):
+			log.Printf("Unsupported media: %s", err)
+			respondWithJSON(w, http.StatusUnsupportedMediaType, returnError{Error: "Unsupported media type"})
+		case errors.Is(err, media.ErrDimensions):
+			log.Printf("Invalid image: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Image dimensions are too large"})
+		case errors.Is(err, media.ErrInvalidImage):
+			log.Printf("Invalid image: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid image"})
+		default:
+			log.Printf("Error storing media: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, resMedia)
+}
+
+func (cfg *ApiConfig) GetMedia(w http.ResponseWriter, r *http.Request) {
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		log.Printf("Invalid mediaID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid media ID"})
+		return
+	}
+
+	dbMedia, err := cfg.DbQueries.GetMediaFile(r.Context(), mediaID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Media not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Media not found"})
+			return
+		}
+		log.Printf("Error getting media: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	cfg.serveMediaBlob(w, r, `"`+dbMedia.ID.String()+`"`, dbMedia.StorageKey, dbMedia.ContentType, dbMedia.SizeBytes)
+}
+
+func (cfg *ApiConfig) GetMediaVariant(w http.ResponseWriter, r *http.Request) {
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		log.Printf("Invalid mediaID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid media ID"})
+		return
+	}
+
+	dbVariant, err := cfg.DbQueries.GetMediaVariant(r.Context(), database.GetMediaVariantParams{
+		MediaID: mediaID,
+		Name:    r.PathValue("variant"),
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Media variant not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Media not found"})
+			return
+		}
+		log.Printf("Error getting media variant: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	etag := `"` + dbVariant.MediaID.String() + "-" + dbVariant.Name + `"`
+	cfg.serveMediaBlob(w, r, etag, dbVariant.StorageKey, dbVariant.ContentType, dbVariant.SizeBytes)
+}
+
+// serveMediaBlob writes a stored file. Media never changes once uploaded,
+// so responses can be cached forever and etag is a strong validator.
+func (cfg *ApiConfig) serveMediaBlob(w http.ResponseWriter, r *http.Request, etag, key, contentType string, size int64) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := cfg.Blobs.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			log.Printf("Media blob not found: %s", key)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Media not found"})
+			return
+		}
+		log.Printf("Error reading media: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("Error writing media: %s", err)
+	}
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 )
 
 func (cfg *ApiConfig) MiddlewareMetricsInc(next http.Handler) http.Handler {
@@ -29,12 +31,22 @@ func (cfg *ApiConfig) MiddlewareMetricsCount(w http.ResponseWriter, r *http.Requ
 	w.Write([]byte(fmt.Sprintf(content, cfg.FileserverHits)))
 }
 
+// MiddlewareMetricsReset moves every user and chirp to the trash, so it only
+// works on the dev platform.
 func (cfg *ApiConfig) MiddlewareMetricsReset(w http.ResponseWriter, r *http.Request) {
+	if cfg.Platform != "dev" {
+		log.Printf("Refusing reset on platform %q", cfg.Platform)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("Forbidden"))
+		return
+	}
+
 	cfg.FileserverHits = 0
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 
-	err := cfg.DbQueries.ResetUsers(r.Context())
+	err := cfg.DbQueries.ResetUsers(r.Context(), sql.NullTime{Time: time.Now(), Valid: true})
 	if err != nil {
 		log.Printf("Error resetting users: %s", err)
 		w.WriteHeader(http.StatusInternalServerError)
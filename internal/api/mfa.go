@@ -0,0 +1,533 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// mfaTokenTTL is how long a user has to enter their second factor
+	// after their password.
+	mfaTokenTTL = 5 * time.Minute
+	// mfaTokenAttempts is how many codes may be tried with one MFA token.
+	mfaTokenAttempts = 5
+	// After mfaMaxFailures wrong codes in a row, whatever the token, the
+	// second factor is locked for mfaLockout.
+	mfaMaxFailures    = 10
+	mfaLockout        = 15 * time.Minute
+	recoveryCodeCount = 10
+	totpIssuer        = "Chirpy"
+)
+
+type mfaChallenge struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type recoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused
+// recovery code. Both are spent by a successful check.
+func (cfg *ApiConfig) checkSecondFactor(ctx context.Context, userID uuid.UUID, code string) (bool, error) {
+	cred, err := cfg.DbQueries.GetTOTPCredential(ctx, userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return false, nil
+		}
+		return false, err
+	}
+	if !cred.EnabledAt.Valid {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(cred.Secret, code, time.Now()); ok {
+		rows, err := cfg.DbQueries.UseTOTPStep(ctx, database.UseTOTPStepParams{
+			UserID:   userID,
+			LastStep: step,
+		})
+		return rows > 0, err
+	}
+
+	rows, err := cfg.DbQueries.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: auth.HashToken(auth.NormalizeRecoveryCode(code)),
+		UsedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	return rows > 0, err
+}
+
+// replaceRecoveryCodes invalidates the user's recovery codes and returns a
+// fresh set. Only their hashes are kept.
+func replaceRecoveryCodes(ctx context.Context, q *database.Queries, userID uuid.UUID) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	err = q.CreateRecoveryCodes(ctx, database.CreateRecoveryCodesParams{
+		UserID:     userID,
+		CodeHashes: hashes,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (cfg *ApiConfig) GetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type resultTwoFactor struct {
+		Enabled                bool  `json:"enabled"`
+		RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	cred, err := cfg.DbQueries.GetTOTPCredential(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			respondWithJSON(w, http.StatusOK, resultTwoFactor{})
+			return
+		}
+		log.Printf("Error getting TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	remaining, err := cfg.DbQueries.CountRecoveryCodes(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error counting recovery codes: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resultTwoFactor{
+		Enabled:                cred.EnabledAt.Valid,
+		RecoveryCodesRemaining: remaining,
+	})
+}
+
+// EnrollTwoFactor starts setting up an authenticator app. Two-factor
+// authentication is only enabled once a code from the app is verified, and
+// starting over replaces the secret until then.
+func (cfg *ApiConfig) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type resultEnrollment struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	rows, err := cfg.DbQueries.UpsertTOTPCredential(r.Context(), database.UpsertTOTPCredentialParams{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error saving TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		log.Printf("User %s already has two-factor authentication", userID)
+		respondWithJSON(w, http.StatusConflict, returnError{Error: "Two-factor authentication already enabled"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, resultEnrollment{
+		Secret: secret,
+		URI:    auth.TOTPURI(secret, totpIssuer, dbUser.Email),
+	})
+}
+
+// VerifyTwoFactor enables two-factor authentication with the first code
+// from the authenticator app, and returns the recovery codes. They are not
+// shown again.
+func (cfg *ApiConfig) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Code string `json:"code"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	cred, err := cfg.DbQueries.GetTOTPCredential(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("TOTP credential not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Two-factor authentication is not being set up"})
+			return
+		}
+		log.Printf("Error getting TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if cred.EnabledAt.Valid {
+		log.Printf("User %s already has two-factor authentication", userID)
+		respondWithJSON(w, http.StatusConflict, returnError{Error: "Two-factor authentication already enabled"})
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(cred.Secret, params.Code, time.Now())
+	if !ok {
+		log.Printf("Invalid TOTP code for user %s", userID)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid code"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	rows, err := qtx.EnableTOTPCredential(r.Context(), database.EnableTOTPCredentialParams{
+		UserID:    userID,
+		EnabledAt: sql.NullTime{Time: time.Now(), Valid: true},
+		LastStep:  step,
+	})
+	if err != nil {
+		log.Printf("Error enabling TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		log.Printf("TOTP credential for user %s changed while verifying", userID)
+		respondWithJSON(w, http.StatusConflict, returnError{Error: "Two-factor authentication already enabled"})
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(r.Context(), qtx, userID)
+	if err != nil {
+		log.Printf("Error creating recovery codes: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, recoveryCodes{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking the
+// password and a code, which may be a recovery code.
+func (cfg *ApiConfig) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := auth.CheckPasswordHash(dbUser.HashedPassword, params.Password); err != nil {
+		log.Printf("Invalid password: %s", err)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Invalid password"})
+		return
+	}
+	ok, err = cfg.checkSecondFactor(r.Context(), userID, params.Code)
+	if err != nil {
+		log.Printf("Error checking second factor: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if !ok {
+		log.Printf("Invalid second factor for user %s", userID)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Invalid code"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.DeleteTOTPCredential(r.Context(), userID); err != nil {
+		log.Printf("Error deleting TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err := qtx.DeleteRecoveryCodes(r.Context(), userID); err != nil {
+		log.Printf("Error deleting recovery codes: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, used or not.
+func (cfg *ApiConfig) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Password string `json:"password"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := auth.CheckPasswordHash(dbUser.HashedPassword, params.Password); err != nil {
+		log.Printf("Invalid password: %s", err)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Invalid password"})
+		return
+	}
+
+	cred, err := cfg.DbQueries.GetTOTPCredential(r.Context(), userID)
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err != nil || !cred.EnabledAt.Valid {
+		log.Printf("User %s does not have two-factor authentication", userID)
+		respondWithJSON(w, http.StatusConflict, returnError{Error: "Two-factor authentication is not enabled"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(r.Context(), cfg.DbQueries.WithTx(tx), userID)
+	if err != nil {
+		log.Printf("Error creating recovery codes: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, recoveryCodes{RecoveryCodes: codes})
+}
+
+// LoginMFA finishes a login that was answered with an MFA challenge, given
+// the challenge token and a TOTP or recovery code.
+func (cfg *ApiConfig) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	userID, challengeID, err := auth.ValidateMFAToken(params.MFAToken, cfg.JwtSecret)
+	if err != nil {
+		log.Printf("Error validating MFA token: %s", err)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	cred, err := cfg.DbQueries.GetTOTPCredential(r.Context(), userID)
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err == nil && cred.LockedUntil.Valid && cred.LockedUntil.Time.After(time.Now()) {
+		log.Printf("Second factor of user %s is locked until %s", userID, cred.LockedUntil.Time)
+		respondWithJSON(w, http.StatusTooManyRequests, returnError{Error: "Too many attempts, try again later"})
+		return
+	}
+
+	// Each attempt is counted before the code is checked, so concurrent
+	// requests cannot get past the limit.
+	rows, err := cfg.DbQueries.UseMFAChallengeAttempt(r.Context(), database.UseMFAChallengeAttemptParams{
+		ID:          challengeID,
+		UserID:      userID,
+		Now:         time.Now(),
+		MaxAttempts: mfaTokenAttempts,
+	})
+	if err != nil {
+		log.Printf("Error using MFA challenge: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		log.Printf("MFA challenge %s is used up or expired", challengeID)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return
+	}
+
+	ok, err := cfg.checkSecondFactor(r.Context(), userID, params.Code)
+	if err != nil {
+		log.Printf("Error checking second factor: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if !ok {
+		err = cfg.DbQueries.RecordTOTPFailure(r.Context(), database.RecordTOTPFailureParams{
+			MaxFailures: mfaMaxFailures,
+			LockedUntil: time.Now().Add(mfaLockout),
+			UserID:      userID,
+		})
+		if err != nil {
+			log.Printf("Error recording second factor failure: %s", err)
+		}
+		log.Printf("Invalid second factor for user %s", userID)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Invalid code"})
+		return
+	}
+
+	// The token is spent once a code is accepted.
+	rows, err = cfg.DbQueries.DeleteMFAChallenge(r.Context(), challengeID)
+	if err != nil {
+		log.Printf("Error deleting MFA challenge: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		log.Printf("MFA challenge %s was already used", challengeID)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return
+	}
+	if err := cfg.DbQueries.ResetTOTPFailures(r.Context(), userID); err != nil {
+		log.Printf("Error resetting second factor failures: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	cfg.startSession(w, r, dbUser)
+}
@@ -0,0 +1,836 @@
+package api
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	oauthCodeTTL         = 10 * time.Minute
+	oauthAccessTokenTTL  = time.Hour
+	oauthRefreshTokenTTL = 60 * 24 * time.Hour
+	maxRedirectURIs      = 10
+	maxClientNameLength  = 100
+)
+
+type OAuthClient struct {
+	ID string `json:"client_id"`
+	// Secret is only shown when the client is registered.
+	Secret       string    `json:"client_secret,omitempty"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func oauthClientFromDatabase(dbClient database.OauthClient) OAuthClient {
+	return OAuthClient{
+		ID:           dbClient.ID,
+		Name:         dbClient.Name,
+		RedirectURIs: dbClient.RedirectUris,
+		Confidential: dbClient.SecretHash.Valid,
+		CreatedAt:    dbClient.CreatedAt,
+	}
+}
+
+type OAuthConsent struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// oauthError is the error body of the token, introspection and revocation
+// endpoints, which RFC 6749 section 5.2 defines.
+type oauthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// validRedirectURI accepts absolute HTTPS URIs without fragments, and plain
+// HTTP on the loopback interface for apps running on the user's machine.
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		if u.Hostname() == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(u.Hostname())
+		return ip != nil && ip.IsLoopback()
+	default:
+		return false
+	}
+}
+
+func (cfg *ApiConfig) CreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		// Confidential clients run on a server and get a secret. Public
+		// clients, such as mobile apps, only have PKCE.
+		Confidential bool `json:"confidential"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || len(params.Name) > maxClientNameLength {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid client name"})
+		return
+	}
+	if len(params.RedirectURIs) == 0 || len(params.RedirectURIs) > maxRedirectURIs {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Between 1 and 10 redirect URIs are required"})
+		return
+	}
+	for _, uri := range params.RedirectURIs {
+		if !validRedirectURI(uri) {
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid redirect URI: " + uri})
+			return
+		}
+	}
+
+	secret := ""
+	secretHash := sql.NullString{}
+	if params.Confidential {
+		secret, err = auth.MakeRefreshToken()
+		if err != nil {
+			log.Printf("Error creating client secret: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+		secretHash = sql.NullString{String: auth.HashToken(secret), Valid: true}
+	}
+
+	dbClient, err := cfg.DbQueries.CreateOAuthClient(r.Context(), database.CreateOAuthClientParams{
+		ID:           uuid.NewString(),
+		SecretHash:   secretHash,
+		Name:         params.Name,
+		RedirectUris: params.RedirectURIs,
+		UserID:       userID,
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error creating OAuth client: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	client := oauthClientFromDatabase(dbClient)
+	client.Secret = secret
+	respondWithJSON(w, http.StatusCreated, client)
+}
+
+// GetOAuthClients lists the clients the user registered.
+func (cfg *ApiConfig) GetOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbClients, err := cfg.DbQueries.GetOAuthClientsByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting OAuth clients: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	clients := make([]OAuthClient, len(dbClients))
+	for i, dbClient := range dbClients {
+		clients[i] = oauthClientFromDatabase(dbClient)
+	}
+	respondWithJSON(w, http.StatusOK, clients)
+}
+
+// DeleteOAuthClient removes a client the user registered, along with every
+// token it was issued.
+func (cfg *ApiConfig) DeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.DbQueries.DeleteOAuthClient(r.Context(), database.DeleteOAuthClientParams{
+		ID:     r.PathValue("clientID"),
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error deleting OAuth client: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Client not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizationRequest holds the parameters of RFC 6749 section 4.1.1,
+// with the PKCE extension of RFC 7636.
+type authorizationRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+type authorization struct {
+	client database.OauthClient
+	scopes []string
+}
+
+// checkAuthorizationRequest validates an authorization request. Chirpy has
+// no login pages of its own, so the app shows the consent screen and both
+// steps answer with JSON rather than redirecting the browser.
+func (cfg *ApiConfig) checkAuthorizationRequest(w http.ResponseWriter, r *http.Request, req authorizationRequest) (authorization, bool) {
+	dbClient, err := cfg.DbQueries.GetOAuthClient(r.Context(), req.ClientID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Unknown client"})
+			return authorization{}, false
+		}
+		log.Printf("Error getting OAuth client: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return authorization{}, false
+	}
+	// Redirect URIs must match a registered one exactly, or codes could be
+	// sent anywhere.
+	if !slices.Contains(dbClient.RedirectUris, req.RedirectURI) {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Redirect URI is not registered for this client"})
+		return authorization{}, false
+	}
+	if req.ResponseType != "code" {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Only the code response type is supported"})
+		return authorization{}, false
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "A PKCE code challenge using S256 is required"})
+		return authorization{}, false
+	}
+	scopes, err := auth.ParseScope(req.Scope)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid scope"})
+		return authorization{}, false
+	}
+
+	return authorization{client: dbClient, scopes: scopes}, true
+}
+
+// GetOAuthAuthorization describes an authorization request so the app can
+// ask the user for consent. Consented is set when the user already allowed
+// every requested scope, and the app may approve without asking again.
+func (cfg *ApiConfig) GetOAuthAuthorization(w http.ResponseWriter, r *http.Request) {
+	type authorizationInfo struct {
+		ClientID    string   `json:"client_id"`
+		ClientName  string   `json:"client_name"`
+		RedirectURI string   `json:"redirect_uri"`
+		Scopes      []string `json:"scopes"`
+		Consented   bool     `json:"consented"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	req := authorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+	authz, ok := cfg.checkAuthorizationRequest(w, r, req)
+	if !ok {
+		return
+	}
+
+	consented := false
+	consent, err := cfg.DbQueries.GetOAuthConsent(r.Context(), database.GetOAuthConsentParams{
+		UserID:   userID,
+		ClientID: authz.client.ID,
+	})
+	if err == nil {
+		consented = true
+		for _, scope := range authz.scopes {
+			consented = consented && slices.Contains(consent.Scopes, scope)
+		}
+	} else if err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting OAuth consent: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, authorizationInfo{
+		ClientID:    authz.client.ID,
+		ClientName:  authz.client.Name,
+		RedirectURI: req.RedirectURI,
+		Scopes:      authz.scopes,
+		Consented:   consented,
+	})
+}
+
+// Authorize records the user's answer to an authorization request and
+// returns where to send the browser: back to the client with either an
+// authorization code or an access_denied error.
+func (cfg *ApiConfig) Authorize(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		authorizationRequest
+		Approve bool `json:"approve"`
+	}
+	type authorizeResponse struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	authz, ok := cfg.checkAuthorizationRequest(w, r, params.authorizationRequest)
+	if !ok {
+		return
+	}
+
+	redirect, _ := url.Parse(params.RedirectURI)
+	query := redirect.Query()
+	if params.State != "" {
+		query.Set("state", params.State)
+	}
+	if !params.Approve {
+		query.Set("error", "access_denied")
+		redirect.RawQuery = query.Encode()
+		respondWithJSON(w, http.StatusOK, authorizeResponse{RedirectTo: redirect.String()})
+		return
+	}
+
+	code, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error creating authorization code: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	// Consent accumulates, so approving a narrower request later does not
+	// take away scopes granted before.
+	scopes := authz.scopes
+	consent, err := qtx.GetOAuthConsent(r.Context(), database.GetOAuthConsentParams{
+		UserID:   userID,
+		ClientID: authz.client.ID,
+	})
+	if err == nil {
+		scopes, _ = auth.ParseScope(auth.FormatScope(append(consent.Scopes, scopes...)))
+	} else if err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting OAuth consent: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	err = qtx.UpsertOAuthConsent(r.Context(), database.UpsertOAuthConsentParams{
+		UserID:    userID,
+		ClientID:  authz.client.ID,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error saving OAuth consent: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	err = qtx.CreateOAuthCode(r.Context(), database.CreateOAuthCodeParams{
+		CodeHash:      auth.HashToken(code),
+		ClientID:      authz.client.ID,
+		UserID:        userID,
+		RedirectUri:   params.RedirectURI,
+		Scopes:        authz.scopes,
+		CodeChallenge: params.CodeChallenge,
+		CreatedAt:     time.Now(),
+		ExpiresAt:     time.Now().Add(oauthCodeTTL),
+	})
+	if err != nil {
+		log.Printf("Error creating authorization code: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	query.Set("code", code)
+	redirect.RawQuery = query.Encode()
+	respondWithJSON(w, http.StatusOK, authorizeResponse{RedirectTo: redirect.String()})
+}
+
+// authenticateClient identifies the client calling the token, introspection
+// or revocation endpoint, from HTTP basic auth or the client_id and
+// client_secret form parameters. Confidential clients must present their
+// secret; public clients only name themselves.
+func (cfg *ApiConfig) authenticateClient(w http.ResponseWriter, r *http.Request) (database.OauthClient, bool) {
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749 section 2.3.1 form-encodes credentials in the header.
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	dbClient, err := cfg.DbQueries.GetOAuthClient(r.Context(), clientID)
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting OAuth client: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return database.OauthClient{}, false
+	}
+	valid := err == nil
+	if valid && dbClient.SecretHash.Valid {
+		valid = subtle.ConstantTimeCompare([]byte(auth.HashToken(secret)), []byte(dbClient.SecretHash.String)) == 1
+	} else if valid {
+		valid = secret == ""
+	}
+	if !valid {
+		log.Printf("Invalid credentials for OAuth client %q", clientID)
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="chirpy"`)
+		}
+		respondWithJSON(w, http.StatusUnauthorized, oauthError{Error: "invalid_client"})
+		return database.OauthClient{}, false
+	}
+	return dbClient, true
+}
+
+// parseOAuthForm reads the form-encoded body the OAuth endpoints take.
+func parseOAuthForm(w http.ResponseWriter, r *http.Request) bool {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("Invalid form: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_request", ErrorDescription: "Invalid form body"})
+		return false
+	}
+	return true
+}
+
+// IssueOAuthToken is the token endpoint of RFC 6749 section 3.2. It
+// redeems authorization codes and refresh tokens.
+func (cfg *ApiConfig) IssueOAuthToken(w http.ResponseWriter, r *http.Request) {
+	if !parseOAuthForm(w, r) {
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	dbClient, ok := cfg.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		cfg.redeemOAuthCode(w, r, dbClient)
+	case "refresh_token":
+		cfg.refreshOAuthGrant(w, r, dbClient)
+	default:
+		respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "unsupported_grant_type"})
+	}
+}
+
+func (cfg *ApiConfig) redeemOAuthCode(w http.ResponseWriter, r *http.Request, dbClient database.OauthClient) {
+	code, err := cfg.DbQueries.TakeOAuthCode(r.Context(), auth.HashToken(r.PostForm.Get("code")))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: "Unknown or used authorization code"})
+			return
+		}
+		log.Printf("Error getting authorization code: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+
+	if code.ClientID != dbClient.ID || code.ExpiresAt.Before(time.Now()) || code.RedirectUri != r.PostForm.Get("redirect_uri") {
+		respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: "Invalid authorization code"})
+		return
+	}
+	if !auth.VerifyPKCE(r.PostForm.Get("code_verifier"), code.CodeChallenge) {
+		respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: "Code verifier does not match the code challenge"})
+		return
+	}
+	if !cfg.checkOAuthUser(w, r, code.UserID) {
+		return
+	}
+
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error creating refresh token: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+	grant, err := cfg.DbQueries.CreateOAuthGrant(r.Context(), database.CreateOAuthGrantParams{
+		ID:               uuid.New(),
+		RefreshTokenHash: auth.HashToken(refreshToken),
+		ClientID:         dbClient.ID,
+		UserID:           code.UserID,
+		Scopes:           code.Scopes,
+		CreatedAt:        time.Now(),
+		ExpiresAt:        time.Now().Add(oauthRefreshTokenTTL),
+	})
+	if err != nil {
+		log.Printf("Error creating OAuth grant: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+
+	cfg.respondWithOAuthToken(w, grant, grant.Scopes, refreshToken)
+}
+
+// checkOAuthUser refuses to issue tokens for a user who has since been
+// deleted or may no longer use their account.
+func (cfg *ApiConfig) checkOAuthUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User %s of OAuth grant not found: %s", userID, err)
+			respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: "User no longer exists"})
+			return false
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return false
+	}
+	if problem := standingProblem(dbUser); problem != "" {
+		log.Printf("User %s of OAuth grant refused: %s", userID, problem)
+		respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: problem})
+		return false
+	}
+	return true
+}
+
+// refreshOAuthGrant exchanges a refresh token for a new access token and a
+// new refresh token. The client may ask for fewer scopes than it was
+// granted, but not more.
+func (cfg *ApiConfig) refreshOAuthGrant(w http.ResponseWriter, r *http.Request, dbClient database.OauthClient) {
+	oldHash := auth.HashToken(r.PostForm.Get("refresh_token"))
+	grant, err := cfg.DbQueries.GetOAuthGrantByRefreshToken(r.Context(), oldHash)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: "Unknown refresh token"})
+			return
+		}
+		log.Printf("Error getting OAuth grant: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+	if grant.ClientID != dbClient.ID || grant.RevokedAt.Valid || grant.ExpiresAt.Before(time.Now()) {
+		respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: "Refresh token is expired or revoked"})
+		return
+	}
+	if !cfg.checkOAuthUser(w, r, grant.UserID) {
+		return
+	}
+
+	scopes := grant.Scopes
+	if scope := r.PostForm.Get("scope"); scope != "" {
+		scopes, err = auth.ParseScope(scope)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_scope"})
+			return
+		}
+		for _, s := range scopes {
+			if !slices.Contains(grant.Scopes, s) {
+				respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_scope", ErrorDescription: "Scope exceeds the original grant"})
+				return
+			}
+		}
+	}
+
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Printf("Error creating refresh token: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+	rows, err := cfg.DbQueries.RotateOAuthGrant(r.Context(), database.RotateOAuthGrantParams{
+		RefreshTokenHash:    auth.HashToken(refreshToken),
+		UpdatedAt:           time.Now(),
+		ExpiresAt:           time.Now().Add(oauthRefreshTokenTTL),
+		ID:                  grant.ID,
+		OldRefreshTokenHash: oldHash,
+	})
+	if err != nil {
+		log.Printf("Error rotating refresh token: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+	// Another request used the same refresh token first.
+	if rows == 0 {
+		respondWithJSON(w, http.StatusBadRequest, oauthError{Error: "invalid_grant", ErrorDescription: "Refresh token was already used"})
+		return
+	}
+
+	cfg.respondWithOAuthToken(w, grant, scopes, refreshToken)
+}
+
+func (cfg *ApiConfig) respondWithOAuthToken(w http.ResponseWriter, grant database.OauthGrant, scopes []string, refreshToken string) {
+	accessToken, err := auth.MakeOAuthToken(grant.UserID, grant.ClientID, grant.ID, scopes, cfg.JwtSecret, oauthAccessTokenTTL)
+	if err != nil {
+		log.Printf("Error creating JWT: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, oauthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(oauthAccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        auth.FormatScope(scopes),
+	})
+}
+
+// IntrospectOAuthToken is the introspection endpoint of RFC 7662.
+// Confidential clients can look up the access and refresh tokens they were
+// issued; anything else is reported inactive.
+func (cfg *ApiConfig) IntrospectOAuthToken(w http.ResponseWriter, r *http.Request) {
+	if !parseOAuthForm(w, r) {
+		return
+	}
+
+	dbClient, ok := cfg.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+	if !dbClient.SecretHash.Valid {
+		respondWithJSON(w, http.StatusUnauthorized, oauthError{Error: "invalid_client", ErrorDescription: "Only confidential clients can introspect tokens"})
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if accessToken, err := cfg.parseAccessToken(r.Context(), token); err == nil && accessToken.ClientID == dbClient.ID {
+		respondWithJSON(w, http.StatusOK, introspectionResponse{
+			Active:    true,
+			Scope:     auth.FormatScope(accessToken.Scopes),
+			ClientID:  accessToken.ClientID,
+			Subject:   accessToken.UserID.String(),
+			TokenType: "access_token",
+			IssuedAt:  accessToken.IssuedAt.Unix(),
+			ExpiresAt: accessToken.ExpiresAt.Unix(),
+		})
+		return
+	}
+
+	grant, err := cfg.DbQueries.GetOAuthGrantByRefreshToken(r.Context(), auth.HashToken(token))
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting OAuth grant: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, oauthError{Error: "server_error"})
+		return
+	}
+	if err != nil || grant.ClientID != dbClient.ID || grant.RevokedAt.Valid || grant.ExpiresAt.Before(time.Now()) {
+		respondWithJSON(w, http.StatusOK, introspectionResponse{Active: false})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, introspectionResponse{
+		Active:    true,
+		Scope:     auth.FormatScope(grant.Scopes),
+		ClientID:  grant.ClientID,
+		Subject:   grant.UserID.String(),
+		TokenType: "refresh_token",
+		IssuedAt:  grant.UpdatedAt.Unix(),
+		ExpiresAt: grant.ExpiresAt.Unix(),
+	})
+}
+
+// RevokeOAuthToken is the revocation endpoint of RFC 7009. Revoking either
+// token of a grant revokes both. Unknown tokens are not an error, so the
+// answer is always 200 once the client is authenticated.
+func (cfg *ApiConfig) RevokeOAuthToken(w http.ResponseWriter, r *http.Request) {
+	if !parseOAuthForm(w, r) {
+		return
+	}
+
+	dbClient, ok := cfg.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	grantID := uuid.Nil
+	grant, err := cfg.DbQueries.GetOAuthGrantByRefreshToken(r.Context(), auth.HashToken(token))
+	if err == nil && grant.ClientID == dbClient.ID {
+		grantID = grant.ID
+	} else if err != nil && err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting OAuth grant: %s", err)
+		respondWithJSON(w, http.StatusServiceUnavailable, oauthError{Error: "temporarily_unavailable"})
+		return
+	} else if accessToken, err := auth.ParseAccessToken(token, cfg.JwtSecret); err == nil && accessToken.ClientID == dbClient.ID {
+		grantID = accessToken.GrantID
+	}
+
+	if grantID != uuid.Nil {
+		err = cfg.DbQueries.RevokeOAuthGrant(r.Context(), database.RevokeOAuthGrantParams{
+			ID:        grantID,
+			RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			log.Printf("Error revoking OAuth grant: %s", err)
+			respondWithJSON(w, http.StatusServiceUnavailable, oauthError{Error: "temporarily_unavailable"})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetOAuthConsents lists the clients the user allowed to act for them.
+func (cfg *ApiConfig) GetOAuthConsents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbConsents, err := cfg.DbQueries.GetOAuthConsentsByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting OAuth consents: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	consents := make([]OAuthConsent, len(dbConsents))
+	for i, dbConsent := range dbConsents {
+		consents[i] = OAuthConsent{
+			ClientID:   dbConsent.ClientID,
+			ClientName: dbConsent.Name,
+			Scopes:     dbConsent.Scopes,
+			CreatedAt:  dbConsent.CreatedAt,
+			UpdatedAt:  dbConsent.UpdatedAt,
+		}
+	}
+	respondWithJSON(w, http.StatusOK, consents)
+}
+
+// DeleteOAuthConsent withdraws the user's consent for a client and revokes
+// every token the client holds for them.
+func (cfg *ApiConfig) DeleteOAuthConsent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+	clientID := r.PathValue("clientID")
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	rows, err := qtx.DeleteOAuthConsent(r.Context(), database.DeleteOAuthConsentParams{
+		UserID:   userID,
+		ClientID: clientID,
+	})
+	if err != nil {
+		log.Printf("Error deleting OAuth consent: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Consent not found"})
+		return
+	}
+
+	err = qtx.RevokeOAuthGrantsByClient(r.Context(), database.RevokeOAuthGrantsByClientParams{
+		UserID:    userID,
+		ClientID:  clientID,
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error revoking OAuth grants: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
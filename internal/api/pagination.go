@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var errInvalidPagination = errors.New("invalid pagination")
+
+// parsePagination reads the optional limit and offset query parameters.
+// When no limit is given defaultLimit is used, where zero means unbounded.
+func parsePagination(r *http.Request, defaultLimit int) (limit, offset int, err error) {
+	limit = defaultLimit
+
+	queryLimit := r.URL.Query().Get("limit")
+	if queryLimit != "" {
+		limit, err = strconv.Atoi(queryLimit)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, errInvalidPagination
+		}
+	}
+
+	queryOffset := r.URL.Query().Get("offset")
+	if queryOffset != "" {
+		offset, err = strconv.Atoi(queryOffset)
+		if err != nil || offset < 0 {
+			return 0, 0, errInvalidPagination
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// paginate applies limit and offset to an already ordered listing.
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+)
+
+const (
+	// Links in older chirps are not fetched anymore.
+	previewMaxAge      = 24 * time.Hour
+	previewBatchSize   = 20
+	previewConcurrency = 4
+)
+
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// loadLinkPreviews attaches the previews that were fetched successfully.
+func (cfg *ApiConfig) loadLinkPreviews(ctx context.Context, chirps []Chirp) error {
+	urls := make([]string, 0)
+	for _, chirp := range chirps {
+		if chirp.previewURL != "" && !chirp.Deleted {
+			urls = append(urls, chirp.previewURL)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	dbPreviews, err := cfg.DbQueries.GetLinkPreviewsByURLs(ctx, urls)
+	if err != nil {
+		return err
+	}
+	byURL := make(map[string]*LinkPreview, len(dbPreviews))
+	for _, p := range dbPreviews {
+		byURL[p.Url] = &LinkPreview{
+			URL:         p.Url,
+			Title:       p.Title,
+			Description: p.Description,
+			ImageURL:    p.ImageUrl,
+			SiteName:    p.SiteName,
+		}
+	}
+	for i := range chirps {
+		if !chirps[i].Deleted {
+			chirps[i].Preview = byURL[chirps[i].previewURL]
+		}
+	}
+	return nil
+}
+
+// fetchPendingPreviews fetches the links of recent chirps that have no
+// cached preview yet. Failures are cached as well.
+func (cfg *ApiConfig) fetchPendingPreviews(ctx context.Context) error {
+	urls, err := cfg.DbQueries.GetPendingPreviewURLs(ctx, database.GetPendingPreviewURLsParams{
+		Since: time.Now().Add(-previewMaxAge),
+		Limit: previewBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, previewConcurrency)
+	for _, url := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			params := database.UpsertLinkPreviewParams{
+				Url:       url,
+				FetchedAt: time.Now(),
+			}
+			p, err := cfg.Unfurler.Fetch(ctx, url)
+			if err != nil {
+				log.Printf("Error fetching preview of %s: %s", url, err)
+			} else {
+				params.Ok = true
+				params.Title = p.Title
+				params.Description = p.Description
+				params.ImageUrl = p.ImageURL
+				params.SiteName = p.SiteName
+			}
+			if err := cfg.DbQueries.UpsertLinkPreview(ctx, params); err != nil {
+				log.Printf("Error saving preview of %s: %s", url, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// RunUnfurler keeps fetching link previews until ctx is done.
+func (cfg *ApiConfig) RunUnfurler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.fetchPendingPreviews(ctx); err != nil {
+			log.Printf("Error fetching link previews: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/entities"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+// Profile is the public view of a user; it never includes the email.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         string    `json:"handle,omitempty"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Avatar         *Media    `json:"avatar,omitempty"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowedByMe   bool      `json:"followed_by_me,omitempty"`
+}
+
+// optional tells a JSON field that was left out from one that was set, to
+// null or otherwise.
+type optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	o.Value = new(T)
+	return json.Unmarshal(data, o.Value)
+}
+
+// resolveUser finds the user addressed by an ID or an @handle.
+func (cfg *ApiConfig) resolveUser(ctx context.Context, handleOrID string) (uuid.UUID, error) {
+	if userID, err := uuid.Parse(handleOrID); err == nil {
+		return userID, nil
+	}
+	dbUser, err := cfg.DbQueries.GetUserByHandle(ctx, strings.TrimPrefix(handleOrID, "@"))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return dbUser.ID, nil
+}
+
+func (cfg *ApiConfig) loadProfile(ctx context.Context, userID uuid.UUID, viewerID uuid.NullUUID) (Profile, error) {
+	dbProfile, err := cfg.DbQueries.GetUserProfile(ctx, userID)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile := Profile{
+		ID:             dbProfile.ID,
+		CreatedAt:      dbProfile.CreatedAt,
+		Handle:         dbProfile.Handle.String,
+		DisplayName:    dbProfile.DisplayName,
+		Bio:            dbProfile.Bio,
+		IsChirpyRed:    dbProfile.IsChirpyRed,
+		FollowerCount:  dbProfile.FollowerCount,
+		FollowingCount: dbProfile.FollowingCount,
+		ChirpCount:     dbProfile.ChirpCount,
+	}
+
+	if dbProfile.AvatarMediaID.Valid {
+		dbMedia, err := cfg.DbQueries.GetMediaFile(ctx, dbProfile.AvatarMediaID.UUID)
+		if err != nil {
+			return Profile{}, err
+		}
+		avatar := mediaFromDatabase(dbMedia)
+		if err := cfg.loadMediaVariants(ctx, []*Media{&avatar}); err != nil {
+			return Profile{}, err
+		}
+		profile.Avatar = &avatar
+	}
+
+	if viewerID.Valid && viewerID.UUID != userID {
+		profile.FollowedByMe, err = cfg.DbQueries.IsFollowing(ctx, database.IsFollowingParams{
+			FollowerID: viewerID.UUID,
+			FollowedID: userID,
+		})
+		if err != nil {
+			return Profile{}, err
+		}
+	}
+	return profile, nil
+}
+
+func (cfg *ApiConfig) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.resolveUser(r.Context(), r.PathValue("handleOrID"))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	profile, err := cfg.loadProfile(r.Context(), userID, cfg.viewerID(r))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting profile: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+// UpdateProfile changes the fields present in the request. Handles and
+// avatars are removed by setting them to null.
+func (cfg *ApiConfig) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Handle        optional[string]    `json:"handle"`
+		DisplayName   optional[string]    `json:"display_name"`
+		Bio           optional[string]    `json:"bio"`
+		AvatarMediaID optional[uuid.UUID] `json:"avatar_media_id"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeProfile)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	update := database.UpdateProfileParams{
+		ID:            dbUser.ID,
+		Handle:        dbUser.Handle,
+		DisplayName:   dbUser.DisplayName,
+		Bio:           dbUser.Bio,
+		AvatarMediaID: dbUser.AvatarMediaID,
+		UpdatedAt:     time.Now(),
+	}
+
+	if params.Handle.Set {
+		update.Handle = sql.NullString{}
+		if params.Handle.Value != nil {
+			handle := strings.TrimPrefix(*params.Handle.Value, "@")
+			if err := entities.ValidateHandle(handle); err != nil {
+				log.Printf("Invalid handle %q: %s", handle, err)
+				if errors.Is(err, entities.ErrReservedHandle) {
+					respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Handle is reserved"})
+					return
+				}
+				respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid handle"})
+				return
+			}
+			update.Handle = sql.NullString{String: handle, Valid: true}
+		}
+	}
+
+	if params.DisplayName.Set {
+		update.DisplayName = ""
+		if params.DisplayName.Value != nil {
+			update.DisplayName = strings.TrimSpace(*params.DisplayName.Value)
+		}
+		if utf8.RuneCountInString(update.DisplayName) > maxDisplayNameLength {
+			log.Printf("Display name is too long: %d characters", utf8.RuneCountInString(update.DisplayName))
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Display name is too long"})
+			return
+		}
+	}
+
+	if params.Bio.Set {
+		update.Bio = ""
+		if params.Bio.Value != nil {
+			update.Bio = strings.TrimSpace(*params.Bio.Value)
+		}
+		if utf8.RuneCountInString(update.Bio) > maxBioLength {
+			log.Printf("Bio is too long: %d characters", utf8.RuneCountInString(update.Bio))
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Bio is too long"})
+			return
+		}
+	}
+
+	if params.AvatarMediaID.Set {
+		update.AvatarMediaID = uuid.NullUUID{}
+		if params.AvatarMediaID.Value != nil {
+			// Avatars are picked from the user's own uploads.
+			dbMedia, err := cfg.DbQueries.GetMediaFile(r.Context(), *params.AvatarMediaID.Value)
+			if err != nil && err.Error() != "sql: no rows in result set" {
+				log.Printf("Error getting media: %s", err)
+				respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+				return
+			}
+			if err != nil || dbMedia.UserID != userID {
+				log.Printf("Invalid avatar_media_id %s for user %s", *params.AvatarMediaID.Value, userID)
+				respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid avatar_media_id"})
+				return
+			}
+			update.AvatarMediaID = uuid.NullUUID{UUID: dbMedia.ID, Valid: true}
+		}
+	}
+
+	_, err = cfg.DbQueries.UpdateProfile(r.Context(), update)
+	if err != nil {
+		if err.Error() == "pq: duplicate key value violates unique constraint \"users_handle_key\"" {
+			log.Printf("Handle already in use: %s", err)
+			respondWithJSON(w, http.StatusConflict, returnError{Error: "Handle already in use"})
+			return
+		}
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error updating profile: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	profile, err := cfg.loadProfile(r.Context(), userID, uuid.NullUUID{})
+	if err != nil {
+		log.Printf("Error getting profile: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func (cfg *ApiConfig) setFollow(w http.ResponseWriter, r *http.Request, follow bool) {
+	userID, ok := cfg.authenticate(w, r, auth.ScopeProfile)
+	if !ok {
+		return
+	}
+
+	followedID, err := cfg.resolveUser(r.Context(), r.PathValue("handleOrID"))
+	if err == nil {
+		// Deleted users cannot be followed either.
+		_, err = cfg.DbQueries.GetUserByID(r.Context(), followedID)
+	}
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if followedID == userID {
+		log.Printf("User %s tried to follow themselves", userID)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Cannot follow yourself"})
+		return
+	}
+
+	if follow {
+		_, err = cfg.DbQueries.FollowUser(r.Context(), database.FollowUserParams{
+			FollowerID: userID,
+			FollowedID: followedID,
+			CreatedAt:  time.Now(),
+		})
+	} else {
+		_, err = cfg.DbQueries.UnfollowUser(r.Context(), database.UnfollowUserParams{
+			FollowerID: userID,
+			FollowedID: followedID,
+		})
+	}
+	if err != nil {
+		log.Printf("Error updating follow: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	profile, err := cfg.loadProfile(r.Context(), followedID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting profile: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+func (cfg *ApiConfig) FollowUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setFollow(w, r, true)
+}
+
+func (cfg *ApiConfig) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setFollow(w, r, false)
+}
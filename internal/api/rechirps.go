@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
+
+	"github.com/google/uuid"
+)
+
+// originalChirp returns the chirp with the given ID, or the chirp it
+// rechirps. Deleted and unpublished chirps are reported as not found.
+func (cfg *ApiConfig) originalChirp(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	dbChirp, err := cfg.DbQueries.GetChirp(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if dbChirp.RechirpOf.Valid {
+		dbChirp, err = cfg.DbQueries.GetChirp(ctx, dbChirp.RechirpOf.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if dbChirp.DeletedAt.Valid || dbChirp.Status != chirpPublished {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return dbChirp, nil
+}
+
+// loadEmbeddedChirps embeds the rechirped and quoted chirps. Deleted
+// originals are embedded as tombstones; embedded chirps do not embed
+// further chirps themselves.
+func (cfg *ApiConfig) loadEmbeddedChirps(ctx context.Context, chirps []Chirp, viewerID uuid.NullUUID) error {
+	chirpIDs := make([]uuid.UUID, 0)
+	for _, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			chirpIDs = append(chirpIDs, chirp.RechirpOf.UUID)
+		}
+		if chirp.QuoteOf.Valid {
+			chirpIDs = append(chirpIDs, chirp.QuoteOf.UUID)
+		}
+	}
+	if len(chirpIDs) == 0 {
+		return nil
+	}
+
+	dbChirps, err := cfg.DbQueries.GetChirpsByIDs(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+	embedded := make([]Chirp, len(dbChirps))
+	for i, dbChirp := range dbChirps {
+		embedded[i] = chirpFromDatabase(dbChirp)
+	}
+	if err := cfg.loadOwnDetails(ctx, embedded, viewerID); err != nil {
+		return err
+	}
+
+	byID := make(map[uuid.UUID]*Chirp, len(embedded))
+	for i := range embedded {
+		byID[embedded[i].ID] = &embedded[i]
+	}
+	for i := range chirps {
+		if chirps[i].RechirpOf.Valid {
+			chirps[i].Rechirped = byID[chirps[i].RechirpOf.UUID]
+		}
+		if chirps[i].QuoteOf.Valid {
+			chirps[i].Quoted = byID[chirps[i].QuoteOf.UUID]
+		}
+	}
+	return nil
+}
+
+func (cfg *ApiConfig) Rechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("Invalid chirpID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid chirp ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	// Rechirping a rechirp rechirps the original.
+	dbOriginal, err := cfg.originalChirp(r.Context(), chirpID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Chirp not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Chirp not found"})
+			return
+		}
+		log.Printf("Error getting chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbChirp, err := qtx.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: dbOriginal.ID, Valid: true},
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User %s already rechirped chirp %s", userID, dbOriginal.ID)
+			respondWithJSON(w, http.StatusConflict, returnError{Error: "Chirp already rechirped"})
+			return
+		}
+		log.Printf("Error creating rechirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	_, err = qtx.CreateChirpEvent(r.Context(), database.CreateChirpEventParams{
+		CreatedAt: dbChirp.CreatedAt,
+		EventType: pubsub.ChirpCreated,
+		ChirpID:   dbChirp.ID,
+		UserID:    userID,
+		RechirpOf: dbChirp.RechirpOf,
+	})
+	if err != nil {
+		log.Printf("Error creating chirp event: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), []database.Chirp{dbChirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, resChirps[0])
+}
+
+func (cfg *ApiConfig) UndoRechirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("Invalid chirpID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid chirp ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbChirp, err := qtx.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Rechirp of chirp %s by user %s not found", chirpID, userID)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Rechirp not found"})
+			return
+		}
+		log.Printf("Error deleting rechirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	_, err = qtx.CreateChirpEvent(r.Context(), database.CreateChirpEventParams{
+		CreatedAt: time.Now(),
+		EventType: pubsub.ChirpDeleted,
+		ChirpID:   dbChirp.ID,
+		UserID:    userID,
+	})
+	if err != nil {
+		log.Printf("Error creating chirp event: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
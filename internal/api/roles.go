@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+)
+
+// Roles, from least to most trusted. Each role may do what the ones
+// before it can.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+func roleAtLeast(role, minRole string) bool {
+	return slices.Index(roles, role) >= slices.Index(roles, minRole)
+}
+
+// MiddlewareRole only lets through users holding at least minRole. The role
+// is read from the database on each request, so a demotion applies at once
+// rather than when the access token expires. OAuth clients and API keys are
+// never let through. Handlers get the user with actorFromContext.
+func (cfg *ApiConfig) MiddlewareRole(minRole string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := cfg.authenticate(w, r, "")
+		if !ok {
+			return
+		}
+
+		dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+		if err != nil {
+			if err.Error() == "sql: no rows in result set" {
+				log.Printf("User not found: %s", err)
+				respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+				return
+			}
+			log.Printf("Error getting user: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+
+		if !roleAtLeast(dbUser.Role, minRole) {
+			log.Printf("User %s with role %s needs role %s for %s", userID, dbUser.Role, minRole, r.URL.Path)
+			respondWithJSON(w, http.StatusForbidden, returnError{Error: "Forbidden"})
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey{}, dbUser)))
+	})
+}
+
+func (cfg *ApiConfig) MiddlewareAdmin(next http.Handler) http.Handler {
+	return cfg.MiddlewareRole(RoleAdmin, next)
+}
+
+// SetUserRole changes the role of a user. Admins cannot change their own
+// role, so the last admin cannot lock everyone out.
+func (cfg *ApiConfig) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Role   string `json:"role"`
+		Reason string `json:"reason"`
+	}
+
+	actor := actorFromContext(r.Context())
+	target, ok := cfg.adminTarget(w, r)
+	if !ok {
+		return
+	}
+	if target.ID == actor.ID {
+		respondWithJSON(w, http.StatusConflict, returnError{Error: "Cannot change your own role"})
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !slices.Contains(roles, params.Role) {
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid role"})
+		return
+	}
+	if !checkReason(w, params.Reason) {
+		return
+	}
+
+	details := map[string]any{"role": params.Role, "previous": target.Role}
+	cfg.updateStanding(w, r, actor, target, auditSetRole, params.Reason, details,
+		func(q *database.Queries) (database.User, error) {
+			return q.UpdateUserRole(r.Context(), database.UpdateUserRoleParams{
+				ID:        target.ID,
+				Role:      params.Role,
+				UpdatedAt: time.Now(),
+			})
+		})
+}
+
+// PromoteFirstAdmin makes the user with the given email an admin, as long
+// as there is no admin yet, and records it in the audit log. It reports
+// whether the user was promoted. Concurrent runs are serialised with an
+// advisory lock, as neither would otherwise see the other's admin.
+func (cfg *ApiConfig) PromoteFirstAdmin(ctx context.Context, email string) (bool, error) {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if err := qtx.LockFirstAdmin(ctx); err != nil {
+		return false, err
+	}
+	target, err := qtx.GetUser(ctx, email)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return false, nil
+		}
+		return false, err
+	}
+	rows, err := qtx.PromoteFirstAdmin(ctx, database.PromoteFirstAdminParams{
+		Email:     email,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil || rows == 0 {
+		return false, err
+	}
+
+	details := map[string]any{"role": RoleAdmin, "previous": target.Role}
+	if err := recordAudit(ctx, qtx, database.User{}, auditPromoteAdmin, target, "", details); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const scheduleBatchSize = 100
+
+// publishDueChirps publishes the scheduled chirps whose time has come and
+// returns how many it published. Each chirp is published in a transaction
+// of its own, so one that keeps failing does not hold back the others.
+func (cfg *ApiConfig) publishDueChirps(ctx context.Context) (int, error) {
+	now := time.Now()
+	dbChirps, err := cfg.DbQueries.GetDueChirps(ctx, database.GetDueChirpsParams{
+		PublishAt: sql.NullTime{Time: now, Valid: true},
+		Limit:     scheduleBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, dbChirp := range dbChirps {
+		ok, err := cfg.publishDueChirp(ctx, dbChirp.ID, now)
+		if err != nil {
+			log.Printf("Error publishing scheduled chirp %s: %s", dbChirp.ID, err)
+			continue
+		}
+		if ok {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// publishDueChirp publishes one scheduled chirp. The row stays locked until
+// the transaction ends, so the chirp is published by a single instance; it
+// reports false when another instance has it or got there first.
+func (cfg *ApiConfig) publishDueChirp(ctx context.Context, chirpID uuid.UUID, now time.Time) (bool, error) {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if _, err := qtx.ClaimDueChirp(ctx, chirpID); err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return false, nil
+		}
+		return false, err
+	}
+	dbChirp, err := qtx.PublishChirp(ctx, database.PublishChirpParams{
+		ID:          chirpID,
+		PublishedAt: now,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := publishChirp(ctx, qtx, dbChirp); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RunScheduler publishes scheduled chirps until ctx is done.
+func (cfg *ApiConfig) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back.
+		for {
+			n, err := cfg.publishDueChirps(ctx)
+			if err != nil {
+				log.Printf("Error publishing scheduled chirps: %s", err)
+			}
+			if err != nil || n < scheduleBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *ApiConfig) GetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsRead)
+	if !ok {
+		return
+	}
+
+	dbChirps, err := cfg.DbQueries.GetScheduledChirpsByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting scheduled chirps: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), dbChirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resChirps)
+}
+
+func (cfg *ApiConfig) RescheduleChirp(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		PublishAt time.Time `json:"publish_at"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("Invalid chirpID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid chirp ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+	if !params.PublishAt.After(time.Now()) {
+		log.Printf("publish_at is not in the future: %s", params.PublishAt)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "publish_at must be in the future"})
+		return
+	}
+
+	// Chirps that were published meanwhile no longer match.
+	dbChirp, err := cfg.DbQueries.RescheduleChirp(r.Context(), database.RescheduleChirpParams{
+		ID:        chirpID,
+		UserID:    userID,
+		PublishAt: sql.NullTime{Time: params.PublishAt.Local(), Valid: true},
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Scheduled chirp not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Scheduled chirp not found"})
+			return
+		}
+		log.Printf("Error rescheduling chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), []database.Chirp{dbChirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resChirps[0])
+}
+
+func (cfg *ApiConfig) CancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("Invalid chirpID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid chirp ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	count, err := cfg.DbQueries.CancelScheduledChirp(r.Context(), database.CancelScheduledChirpParams{
+		ID:     chirpID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error cancelling scheduled chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if count == 0 {
+		log.Printf("Scheduled chirp %s of user %s not found", chirpID, userID)
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Scheduled chirp not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type ChirpSearchResult struct {
+	Chirp
+	// Snippet is HTML: the body is escaped and matches are wrapped in
+	// <mark> tags.
+	Snippet string  `json:"snippet"`
+	Rank    float32 `json:"rank"`
+}
+
+// searchTerms splits a word into the lexemes it can form, so user input
+// can never produce tsquery syntax. Punctuation separates lexemes, as in
+// "foo-bar".
+func searchTerms(word string) []string {
+	return strings.FieldsFunc(strings.ToLower(word), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// buildTSQuery converts a search string into to_tsquery syntax. Quoted
+// text becomes a phrase, a trailing * requests prefix matching and a
+// leading - excludes a word. All remaining parts must match.
+func buildTSQuery(q string) string {
+	parts := make([]string, 0)
+	for i, chunk := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			words := make([]string, 0)
+			for _, word := range strings.Fields(chunk) {
+				words = append(words, searchTerms(word)...)
+			}
+			if len(words) > 0 {
+				parts = append(parts, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+		for _, word := range strings.Fields(chunk) {
+			terms := searchTerms(word)
+			if len(terms) == 0 {
+				continue
+			}
+			if strings.HasSuffix(word, "*") {
+				terms[len(terms)-1] += ":*"
+			}
+			// A word split by punctuation must match as a phrase.
+			term := terms[0]
+			if len(terms) > 1 {
+				term = "(" + strings.Join(terms, " <-> ") + ")"
+			}
+			if strings.HasPrefix(word, "-") {
+				term = "!" + term
+			}
+			parts = append(parts, term)
+		}
+	}
+	return strings.Join(parts, " & ")
+}
+
+func parseTimeParam(r *http.Request, name string) (sql.NullTime, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+func (cfg *ApiConfig) SearchChirps(w http.ResponseWriter, r *http.Request) {
+	var authorID uuid.NullUUID
+	var err error
+
+	query := buildTSQuery(r.URL.Query().Get("q"))
+	if query == "" {
+		log.Printf("Missing search query")
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Missing search query"})
+		return
+	}
+
+	queryUserID := r.URL.Query().Get("author_id")
+	if queryUserID != "" {
+		if authorID.UUID, err = uuid.Parse(queryUserID); err != nil {
+			log.Printf("Invalid author_id: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid author_id"})
+			return
+		}
+		authorID.Valid = true
+	}
+
+	since, err := parseTimeParam(r, "since")
+	if err != nil {
+		log.Printf("Invalid since: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid since"})
+		return
+	}
+	until, err := parseTimeParam(r, "until")
+	if err != nil {
+		log.Printf("Invalid until: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid until"})
+		return
+	}
+
+	queryOrder := r.URL.Query().Get("order")
+	if queryOrder != "" && queryOrder != "relevance" && queryOrder != "recent" {
+		log.Printf("Invalid order: %s", queryOrder)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid order"})
+		return
+	}
+
+	limit, offset, err := parsePagination(r, defaultPageSize)
+	if err != nil {
+		log.Printf("Invalid pagination: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid limit or offset"})
+		return
+	}
+
+	dbResults, err := cfg.DbQueries.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:    query,
+		AuthorID: authorID,
+		Since:    since,
+		Until:    until,
+		ByRank:   queryOrder != "recent",
+		Limit:    int32(limit),
+		Offset:   int32(offset),
+	})
+	if err != nil {
+		log.Printf("Error searching chirps: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps := make([]Chirp, len(dbResults))
+	for i, dbResult := range dbResults {
+		resChirps[i] = chirpFromDatabase(dbResult.Chirp)
+	}
+	err = cfg.loadChirpDetails(r.Context(), resChirps, cfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resResults := make([]ChirpSearchResult, len(dbResults))
+	for i, dbResult := range dbResults {
+		resResults[i] = ChirpSearchResult{
+			Chirp:   resChirps[i],
+			Snippet: dbResult.Snippet,
+			Rank:    dbResult.Rank,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, resResults)
+}
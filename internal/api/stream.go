@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
+
+	"github.com/google/uuid"
+)
+
+const (
+	streamBufferSize  = 64
+	streamReplayLimit = 500
+	streamHeartbeat   = 15 * time.Second
+	streamRetry       = 3 * time.Second
+	// streamEventRetention is how far back a client resuming with
+	// Last-Event-ID can catch up. Older events are purged.
+	streamEventRetention = 7 * 24 * time.Hour
+)
+
+type chirpDeleted struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func chirpEventPayload(e pubsub.Event) interface{} {
+	switch e.Type {
+	case pubsub.ChirpCreated, pubsub.ChirpRestored:
+		return Chirp{
+			ID:        e.ChirpID,
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.CreatedAt,
+			Body:      e.Body,
+			UserID:    uuid.NullUUID{UUID: e.UserID, Valid: true},
+			RechirpOf: e.RechirpOf,
+		}
+	case pubsub.ChirpDeleted:
+		return chirpDeleted{
+			ID:     e.ChirpID,
+			UserID: e.UserID,
+		}
+	}
+	return nil
+}
+
+func writeStreamEvent(w http.ResponseWriter, e pubsub.Event) error {
+	payload := chirpEventPayload(e)
+	if payload == nil {
+		return nil
+	}
+
+	dat, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, dat)
+	return err
+}
+
+func (cfg *ApiConfig) StreamChirps(w http.ResponseWriter, r *http.Request) {
+	var userID uuid.NullUUID
+	var lastEventID int64
+	var err error
+
+	queryUserID := r.URL.Query().Get("author_id")
+	if queryUserID != "" {
+		if userID.UUID, err = uuid.Parse(queryUserID); err != nil {
+			log.Printf("Invalid author_id: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid author_id"})
+			return
+		}
+		userID.Valid = true
+	}
+
+	headerLastEventID := r.Header.Get("Last-Event-ID")
+	if headerLastEventID != "" {
+		if lastEventID, err = strconv.ParseInt(headerLastEventID, 10, 64); err != nil {
+			log.Printf("Invalid Last-Event-ID: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid Last-Event-ID"})
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Printf("Streaming not supported by response writer")
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	match := func(e pubsub.Event) bool {
+		return !userID.Valid || e.UserID == userID.UUID
+	}
+
+	// Subscribe before replaying so no event falls between the two.
+	sub := cfg.Broker.Subscribe(streamBufferSize, match)
+	defer cfg.Broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	flusher.Flush()
+
+	// Events sent during replay may also wait on the subscription. Only
+	// those are skipped there: an event with a lower ID can commit after the
+	// replay has read past it, and must still be delivered.
+	replayed := map[int64]bool{}
+	if headerLastEventID != "" {
+		for {
+			dbEvents, err := cfg.DbQueries.GetChirpEventsSince(r.Context(), database.GetChirpEventsSinceParams{
+				ID:    lastEventID,
+				Limit: streamReplayLimit,
+			})
+			if err != nil {
+				log.Printf("Error getting chirp events: %s", err)
+				return
+			}
+			for _, dbEvent := range dbEvents {
+				e := pubsub.FromDatabase(dbEvent)
+				lastEventID = e.ID
+				if !match(e) {
+					continue
+				}
+				if err := writeStreamEvent(w, e); err != nil {
+					log.Printf("Error writing chirp event: %s", err)
+					return
+				}
+				replayed[e.ID] = true
+			}
+			flusher.Flush()
+			if len(dbEvents) < streamReplayLimit {
+				break
+			}
+		}
+	}
+
+	replayHighWater := lastEventID
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				if sub.Lagged() {
+					log.Printf("Closing chirp stream for slow client")
+				}
+				return
+			}
+			if e.ID <= replayHighWater && replayed[e.ID] {
+				continue
+			}
+			if err := writeStreamEvent(w, e); err != nil {
+				log.Printf("Error writing chirp event: %s", err)
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
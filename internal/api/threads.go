@@ -0,0 +1,107 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultThreadDepth = 3
+	maxThreadDepth     = 10
+)
+
+type ThreadChirp struct {
+	Chirp
+	Replies []*ThreadChirp `json:"replies"`
+	// MoreReplies is set on chirps at the depth limit that have replies
+	// which were not included.
+	MoreReplies bool `json:"more_replies"`
+}
+
+type Thread struct {
+	Ancestors []Chirp      `json:"ancestors"`
+	Chirp     *ThreadChirp `json:"chirp"`
+}
+
+func (cfg *ApiConfig) GetChirpThread(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("Invalid chirpID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid chirp ID"})
+		return
+	}
+
+	depth := defaultThreadDepth
+	if queryDepth := r.URL.Query().Get("depth"); queryDepth != "" {
+		depth, err = strconv.Atoi(queryDepth)
+		if err != nil || depth < 0 || depth > maxThreadDepth {
+			log.Printf("Invalid depth: %s", queryDepth)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid depth"})
+			return
+		}
+	}
+
+	dbThread, err := cfg.DbQueries.GetChirpThread(r.Context(), database.GetChirpThreadParams{
+		ID:       chirpID,
+		MaxDepth: int32(depth),
+	})
+	if err != nil {
+		log.Printf("Error getting chirp thread: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if len(dbThread) == 0 {
+		log.Printf("Chirp not found: %s", chirpID)
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Chirp not found"})
+		return
+	}
+
+	dbAncestors, err := cfg.DbQueries.GetChirpAncestors(r.Context(), chirpID)
+	if err != nil {
+		log.Printf("Error getting chirp ancestors: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	// Load the details of the ancestors and the replies in one go.
+	chirps := make([]Chirp, 0, len(dbAncestors)+len(dbThread))
+	for _, dbChirp := range dbAncestors {
+		chirps = append(chirps, chirpFromDatabase(dbChirp))
+	}
+	for _, dbRow := range dbThread {
+		chirps = append(chirps, chirpFromDatabase(dbRow.Chirp))
+	}
+	err = cfg.loadChirpDetails(r.Context(), chirps, cfg.viewerID(r))
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	// Rows come ordered by depth, so every parent is in the tree before
+	// its replies.
+	replies := chirps[len(dbAncestors):]
+	nodes := make(map[uuid.UUID]*ThreadChirp, len(replies))
+	for i, dbRow := range dbThread {
+		node := &ThreadChirp{
+			Chirp:       replies[i],
+			Replies:     make([]*ThreadChirp, 0),
+			MoreReplies: dbRow.HasReplies && int(dbRow.Depth) == depth,
+		}
+		nodes[node.ID] = node
+		if dbRow.Depth > 0 {
+			parent := nodes[node.InReplyTo.UUID]
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, Thread{
+		Ancestors: chirps[:len(dbAncestors)],
+		Chirp:     nodes[chirpID],
+	})
+}
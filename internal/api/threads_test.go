@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func TestGetChirpThreadOfScheduledChirp(t *testing.T) {
+	cfg := newTestConfig(t)
+	dbUser := createTestUser(t, cfg)
+
+	dbChirp, err := cfg.DbQueries.CreateChirp(context.Background(), database.CreateChirpParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Body:      "Not out until tomorrow",
+		UserID:    dbUser.ID,
+		Status:    chirpScheduled,
+		PublishAt: sql.NullTime{Time: time.Now().Add(24 * time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf(`CreateChirp returned an error: %v`, err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/chirps/"+dbChirp.ID.String()+"/thread", nil)
+	req.SetPathValue("chirpID", dbChirp.ID.String())
+	rec := httptest.NewRecorder()
+	cfg.GetChirpThread(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf(`GetChirpThread of a scheduled chirp = %d, want %d`, rec.Code, http.StatusNotFound)
+	}
+	if strings.Contains(rec.Body.String(), dbChirp.Body) {
+		t.Errorf(`GetChirpThread of a scheduled chirp showed its body: %s`, rec.Body.String())
+	}
+}
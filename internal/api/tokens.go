@@ -42,6 +42,11 @@ func (cfg *ApiConfig) GetToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A suspension leaves sessions in place, so it is enforced here too.
+	if !cfg.checkUserStanding(w, r, dbToken.UserID) {
+		return
+	}
+
 	token, err := auth.MakeJWT(dbToken.UserID, cfg.JwtSecret, time.Duration(3600)*time.Second)
 	if err != nil {
 		log.Printf("Error creating JWT: %s", err)
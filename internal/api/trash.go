@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/blobstore"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
+
+	"github.com/google/uuid"
+)
+
+// purgeTrash hard-deletes what has been in the trash for longer than the
+// retention window, along with expired exports, events and challenges. Each
+// kind is purged on its own, so one failing doesn't hold up the rest.
+func (cfg *ApiConfig) purgeTrash(ctx context.Context) {
+	cutoff := sql.NullTime{Time: time.Now().Add(-cfg.TrashRetention), Valid: true}
+
+	if err := cfg.purgeExports(ctx); err != nil {
+		log.Printf("Error purging exports: %s", err)
+	}
+	cfg.purgeExpired(ctx)
+
+	// Chirps go first: tombstones of a purged user's chirps then outlive
+	// them with no author.
+	if err := cfg.purgeChirps(ctx, cutoff); err != nil {
+		log.Printf("Error purging chirps: %s", err)
+	}
+	if err := cfg.purgeUsers(ctx, cutoff); err != nil {
+		log.Printf("Error purging users: %s", err)
+	}
+}
+
+// purgeExpired deletes stream events past their retention and challenges,
+// codes and grants past their expiry.
+func (cfg *ApiConfig) purgeExpired(ctx context.Context) {
+	now := time.Now()
+	expired := []struct {
+		name   string
+		before time.Time
+		purge  func(context.Context, time.Time) (int64, error)
+	}{
+		{"chirp events", now.Add(-streamEventRetention), cfg.DbQueries.DeleteChirpEventsBefore},
+		{"WebAuthn challenges", now, cfg.DbQueries.DeleteExpiredWebAuthnChallenges},
+		{"MFA challenges", now, cfg.DbQueries.DeleteExpiredMFAChallenges},
+		{"OAuth codes", now, cfg.DbQueries.DeleteExpiredOAuthCodes},
+		{"OAuth grants", now, cfg.DbQueries.DeleteExpiredOAuthGrants},
+	}
+	for _, e := range expired {
+		if _, err := e.purge(ctx, e.before); err != nil {
+			log.Printf("Error purging %s: %s", e.name, err)
+		}
+	}
+}
+
+// purgeChirps deletes chirps deleted before cutoff. Those that are still
+// replied to or quoted lose their content but stay behind as tombstones, so
+// threads keep their shape.
+func (cfg *ApiConfig) purgeChirps(ctx context.Context, cutoff sql.NullTime) error {
+	chirps, err := cfg.DbQueries.PurgeDeletedChirps(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	scrubbed, err := cfg.DbQueries.ScrubDeletedChirps(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if chirps+scrubbed > 0 {
+		log.Printf("Purged %d chirps, scrubbed %d chirps", chirps, scrubbed)
+	}
+	return nil
+}
+
+// purgeUsers deletes users deleted before cutoff. Their uploads are removed
+// from storage once their rows are gone.
+func (cfg *ApiConfig) purgeUsers(ctx context.Context, cutoff sql.NullTime) error {
+	mediaKeys, err := cfg.DbQueries.GetMediaKeysOfDeletedUsers(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	variantKeys, err := cfg.DbQueries.GetMediaVariantKeysOfDeletedUsers(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	users, err := cfg.DbQueries.PurgeDeletedUsers(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	for _, key := range append(mediaKeys, variantKeys...) {
+		err := cfg.Blobs.Delete(ctx, key)
+		if err != nil && !errors.Is(err, blobstore.ErrNotFound) {
+			log.Printf("Error deleting media blob %s: %s", key, err)
+		}
+	}
+	if users > 0 {
+		log.Printf("Purged %d users", users)
+	}
+	return nil
+}
+
+// RunPurger empties expired trash until ctx is done.
+func (cfg *ApiConfig) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cfg.purgeTrash(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *ApiConfig) RestoreChirp(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		log.Printf("Invalid chirpID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid chirp ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, auth.ScopeChirpsWrite)
+	if !ok {
+		return
+	}
+
+	dbChirp, err := cfg.DbQueries.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Chirp not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "Chirp not found"})
+			return
+		}
+		log.Printf("Error getting chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if dbChirp.UserID.UUID != userID {
+		log.Printf("User %s is not authorized to restore chirp %s", userID, chirpID)
+		respondWithJSON(w, http.StatusForbidden, returnError{Error: "Forbidden"})
+		return
+	}
+	if !dbChirp.DeletedAt.Valid {
+		log.Printf("Chirp %s is not deleted", chirpID)
+		respondWithJSON(w, http.StatusConflict, returnError{Error: "Chirp is not deleted"})
+		return
+	}
+	if time.Since(dbChirp.DeletedAt.Time) > cfg.TrashRetention {
+		log.Printf("Chirp %s was deleted at %s, past the restore window", chirpID, dbChirp.DeletedAt.Time)
+		respondWithJSON(w, http.StatusGone, returnError{Error: "Chirp can no longer be restored"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	// Rechirps deleted along with the chirp share its deleted_at and come
+	// back with it.
+	err = qtx.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		UpdatedAt: time.Now(),
+		ID:        dbChirp.ID,
+		DeletedAt: dbChirp.DeletedAt,
+	})
+	if err != nil {
+		log.Printf("Error restoring chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	dbChirp, err = qtx.GetChirp(r.Context(), dbChirp.ID)
+	if err != nil {
+		log.Printf("Error getting chirp: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	_, err = qtx.CreateChirpEvent(r.Context(), database.CreateChirpEventParams{
+		CreatedAt: time.Now(),
+		EventType: pubsub.ChirpRestored,
+		ChirpID:   dbChirp.ID,
+		UserID:    dbChirp.UserID.UUID,
+		Body:      dbChirp.Body,
+	})
+	if err != nil {
+		log.Printf("Error creating chirp event: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resChirps, err := cfg.chirpsFromDatabase(r.Context(), []database.Chirp{dbChirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting chirp details: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resChirps[0])
+}
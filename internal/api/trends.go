@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+const (
+	trendWatermark = "hashtag_rollups"
+	// Chirps are counted once they are this old, so rows from transactions
+	// still in flight are not skipped by the watermark.
+	trendRollupLag = time.Minute
+	trendRetention = 48 * time.Hour
+)
+
+var trendWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+}
+
+type Trend struct {
+	Tag           string  `json:"tag"`
+	ChirpCount    int64   `json:"chirp_count"`
+	PreviousCount int64   `json:"previous_count"`
+	Score         float64 `json:"score"`
+}
+
+type HiddenHashtag struct {
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// rollupHashtags adds the hashtags indexed since the last run to the
+// per-minute rollups. The watermark row lock keeps concurrent instances
+// from counting the same chirps twice.
+func (cfg *ApiConfig) rollupHashtags(ctx context.Context) error {
+	tx, err := cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	processedUntil, err := qtx.LockTrendWatermark(ctx, trendWatermark)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			// Another instance is running the rollup.
+			return nil
+		}
+		return err
+	}
+
+	until := time.Now().Add(-trendRollupLag)
+	if !until.After(processedUntil) {
+		return nil
+	}
+
+	err = qtx.RollupHashtags(ctx, database.RollupHashtagsParams{
+		ProcessedFrom:  processedUntil,
+		ProcessedUntil: until,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = qtx.UpdateTrendWatermark(ctx, database.UpdateTrendWatermarkParams{
+		Name:           trendWatermark,
+		ProcessedUntil: until,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = qtx.DeleteHashtagRollups(ctx, time.Now().Add(-trendRetention))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RunTrendAggregator keeps the hashtag rollups up to date until ctx is done.
+func (cfg *ApiConfig) RunTrendAggregator(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.rollupHashtags(ctx); err != nil {
+			log.Printf("Error rolling up hashtags: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *ApiConfig) GetTrends(w http.ResponseWriter, r *http.Request) {
+	queryWindow := r.URL.Query().Get("window")
+	if queryWindow == "" {
+		queryWindow = "1h"
+	}
+	window, ok := trendWindows[queryWindow]
+	if !ok {
+		log.Printf("Invalid window: %s", queryWindow)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid window"})
+		return
+	}
+
+	limit, _, err := parsePagination(r, 10)
+	if err != nil {
+		log.Printf("Invalid pagination: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid limit"})
+		return
+	}
+
+	now := time.Now()
+	dbTrends, err := cfg.DbQueries.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		WindowStart:   now.Add(-window),
+		PreviousStart: now.Add(-2 * window),
+		Limit:         int32(limit),
+	})
+	if err != nil {
+		log.Printf("Error getting trending hashtags: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resTrends := make([]Trend, len(dbTrends))
+	for i, dbTrend := range dbTrends {
+		resTrends[i] = Trend{
+			Tag:           dbTrend.Tag,
+			ChirpCount:    dbTrend.CurrentCount,
+			PreviousCount: dbTrend.PreviousCount,
+			Score:         float64(dbTrend.CurrentCount+1) / float64(dbTrend.PreviousCount+1),
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, resTrends)
+}
+
+func (cfg *ApiConfig) GetHiddenHashtags(w http.ResponseWriter, r *http.Request) {
+	dbHidden, err := cfg.DbQueries.GetHiddenHashtags(r.Context())
+	if err != nil {
+		log.Printf("Error getting hidden hashtags: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resHidden := make([]HiddenHashtag, len(dbHidden))
+	for i, dbHashtag := range dbHidden {
+		resHidden[i] = HiddenHashtag{
+			Tag:       dbHashtag.Tag,
+			CreatedAt: dbHashtag.CreatedAt,
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, resHidden)
+}
+
+func (cfg *ApiConfig) HideHashtag(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Tag string `json:"tag"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	tag := strings.ToLower(strings.TrimPrefix(params.Tag, "#"))
+	if tag == "" {
+		log.Printf("Missing tag")
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Missing tag"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbHashtag, err := qtx.UpsertHashtag(r.Context(), database.UpsertHashtagParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		Tag:       tag,
+	})
+	if err != nil {
+		log.Printf("Error creating hashtag: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	err = qtx.HideHashtag(r.Context(), database.HideHashtagParams{
+		HashtagID: dbHashtag.ID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error hiding hashtag: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	err = recordAudit(r.Context(), qtx, actorFromContext(r.Context()), auditHideHashtag, database.User{}, "", map[string]any{"tag": tag})
+	if err != nil {
+		log.Printf("Error recording audit entry: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *ApiConfig) UnhideHashtag(w http.ResponseWriter, r *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	count, err := qtx.UnhideHashtag(r.Context(), tag)
+	if err != nil {
+		log.Printf("Error unhiding hashtag: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if count == 0 {
+		log.Printf("Hidden hashtag not found: %s", tag)
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Hidden hashtag not found"})
+		return
+	}
+
+	err = recordAudit(r.Context(), qtx, actorFromContext(r.Context()), auditUnhideHashtag, database.User{}, "", map[string]any{"tag": tag})
+	if err != nil {
+		log.Printf("Error recording audit entry: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,14 +1,19 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"net/mail"
 	"time"
 
 	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
 
 	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
 
 	"github.com/google/uuid"
 )
@@ -18,9 +23,99 @@ type User struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	Email        string    `json:"email"`
+	Handle       string    `json:"handle,omitempty"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Role         string    `json:"role"`
 	Token        string    `json:"token,omitempty"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
+	PendingEmail string    `json:"pending_email,omitempty"`
+}
+
+// emailChangeTTL is how long a new email address has to be confirmed.
+const emailChangeTTL = 24 * time.Hour
+
+func userFromDatabase(dbUser database.User) User {
+	return User{
+		ID:          dbUser.ID,
+		CreatedAt:   dbUser.CreatedAt,
+		UpdatedAt:   dbUser.UpdatedAt,
+		Email:       dbUser.Email,
+		Handle:      dbUser.Handle.String,
+		IsChirpyRed: dbUser.IsChirpyRed,
+		Role:        dbUser.Role,
+	}
+}
+
+// checkNewPassword enforces the strength rules on a password being set and
+// refuses those known from breaches. userInputs are the user's own details,
+// which make a password easier to guess.
+func (cfg *ApiConfig) checkNewPassword(ctx context.Context, w http.ResponseWriter, password string, userInputs ...string) bool {
+	if err := auth.CheckPasswordLength(cfg.Hasher, password); err != nil {
+		log.Printf("Password too long: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid password: " + err.Error()})
+		return false
+	}
+	if err := auth.ValidatePassword(password, userInputs...); err != nil {
+		log.Printf("Weak password: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Weak password: " + err.Error()})
+		return false
+	}
+
+	if cfg.Breaches == nil {
+		return true
+	}
+	// An unavailable breach list should not stop people from signing up.
+	breached, err := cfg.Breaches.IsBreached(ctx, password)
+	if err != nil {
+		log.Printf("Error checking password breaches: %s", err)
+		return true
+	}
+	if breached {
+		log.Printf("Password found in breach list")
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "This password has appeared in a data breach, please choose another"})
+		return false
+	}
+	return true
+}
+
+// standingProblem tells why a user may not use their account: they are
+// banned, suspended or must reset their password. It is empty otherwise.
+func standingProblem(dbUser database.User) string {
+	switch {
+	case dbUser.BannedAt.Valid:
+		return "Account is banned"
+	case dbUser.SuspendedUntil.Valid && dbUser.SuspendedUntil.Time.After(time.Now()):
+		return "Account is suspended until " + dbUser.SuspendedUntil.Time.UTC().Format(time.RFC3339)
+	case dbUser.PasswordResetRequired:
+		return "Password reset required"
+	}
+	return ""
+}
+
+// checkStanding refuses users who may not use their account.
+func checkStanding(w http.ResponseWriter, dbUser database.User) bool {
+	if problem := standingProblem(dbUser); problem != "" {
+		log.Printf("User %s refused: %s", dbUser.ID, problem)
+		respondWithJSON(w, http.StatusForbidden, returnError{Error: problem})
+		return false
+	}
+	return true
+}
+
+// checkUserStanding loads a user and checks their standing.
+func (cfg *ApiConfig) checkUserStanding(w http.ResponseWriter, r *http.Request, userID uuid.UUID) bool {
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+			return false
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return false
+	}
+	return checkStanding(w, dbUser)
 }
 
 func (cfg *ApiConfig) CreateUser(w http.ResponseWriter, r *http.Request) {
@@ -38,7 +133,11 @@ func (cfg *ApiConfig) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hash, err := auth.HashPassword(params.Password)
+	if !cfg.checkNewPassword(r.Context(), w, params.Password, params.Email) {
+		return
+	}
+
+	hash, err := cfg.Hasher.Hash(params.Password)
 	if err != nil {
 		log.Printf("Error hashing password: %s", err)
 		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
@@ -106,6 +205,68 @@ func (cfg *ApiConfig) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Hashes made with older settings are replaced while the password is at
+	// hand. Failing to do so is not worth failing the login over.
+	if cfg.Hasher.NeedsRehash(dbUser.HashedPassword) {
+		hash, err := cfg.Hasher.Hash(params.Password)
+		if err == nil {
+			err = cfg.DbQueries.RehashUserPassword(r.Context(), database.RehashUserPasswordParams{
+				NewHash: hash,
+				ID:      dbUser.ID,
+				OldHash: dbUser.HashedPassword,
+			})
+		}
+		if err != nil {
+			log.Printf("Error rehashing password: %s", err)
+		}
+	}
+
+	cfg.completeLogin(w, r, dbUser)
+}
+
+// completeLogin starts a session for a user who has proved one factor.
+// With two-factor authentication on, that only earns a challenge token to
+// finish the login with at /api/login/mfa.
+func (cfg *ApiConfig) completeLogin(w http.ResponseWriter, r *http.Request, dbUser database.User) {
+	cred, err := cfg.DbQueries.GetTOTPCredential(r.Context(), dbUser.ID)
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		log.Printf("Error getting TOTP credential: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err == nil && cred.EnabledAt.Valid {
+		challengeID := uuid.New()
+		err := cfg.DbQueries.CreateMFAChallenge(r.Context(), database.CreateMFAChallengeParams{
+			ID:        challengeID,
+			UserID:    dbUser.ID,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(mfaTokenTTL),
+		})
+		if err != nil {
+			log.Printf("Error creating MFA challenge: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+		mfaToken, err := auth.MakeMFAToken(dbUser.ID, challengeID, cfg.JwtSecret, mfaTokenTTL)
+		if err != nil {
+			log.Printf("Error creating MFA token: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+		respondWithJSON(w, http.StatusOK, mfaChallenge{MFARequired: true, MFAToken: mfaToken})
+		return
+	}
+
+	cfg.startSession(w, r, dbUser)
+}
+
+// startSession issues an access token and a refresh token to a user who has
+// just logged in.
+func (cfg *ApiConfig) startSession(w http.ResponseWriter, r *http.Request, dbUser database.User) {
+	if !checkStanding(w, dbUser) {
+		return
+	}
+
 	token, err := auth.MakeJWT(dbUser.ID, cfg.JwtSecret, time.Duration(3600)*time.Second)
 	if err != nil {
 		log.Printf("Error creating JWT: %s", err)
@@ -138,68 +299,357 @@ func (cfg *ApiConfig) GetUser(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:    dbUser.CreatedAt,
 		UpdatedAt:    dbUser.UpdatedAt,
 		Email:        dbUser.Email,
+		Handle:       dbUser.Handle.String,
 		IsChirpyRed:  dbUser.IsChirpyRed,
+		Role:         dbUser.Role,
 		Token:        token,
 		RefreshToken: dbToken.Token,
 	}
 	respondWithJSON(w, http.StatusOK, resUser)
 }
 
+// UpdateUser changes the email address or password of the user. Both are
+// optional, and changing either requires the current password. A new
+// password takes effect at once and signs out other sessions; a new email
+// address only replaces the old one once it is confirmed.
 func (cfg *ApiConfig) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	type paramRequest struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email           *string `json:"email"`
+		Password        *string `json:"password"`
+		CurrentPassword string  `json:"current_password"`
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
 	if err != nil {
-		log.Printf("Error getting bearer token: %s", err)
-		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
 		return
 	}
-	userID, err := auth.ValidateJWT(token, cfg.JwtSecret)
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
 	if err != nil {
-		log.Printf("Error validating token: %s", err)
-		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
 		return
 	}
 
+	if params.Email != nil && *params.Email == dbUser.Email {
+		params.Email = nil
+	}
+	if params.Email == nil && params.Password == nil {
+		respondWithJSON(w, http.StatusOK, userFromDatabase(dbUser))
+		return
+	}
+
+	// The password is checked before anything else, so the address check
+	// below can't be used to tell which emails have accounts.
+	if err := auth.CheckPasswordHash(dbUser.HashedPassword, params.CurrentPassword); err != nil {
+		log.Printf("Invalid password: %s", err)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Invalid password"})
+		return
+	}
+
+	if params.Email != nil {
+		if addr, err := mail.ParseAddress(*params.Email); err != nil || addr.Address != *params.Email {
+			log.Printf("Invalid email %q", *params.Email)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid email"})
+			return
+		}
+		_, err := cfg.DbQueries.GetUser(r.Context(), *params.Email)
+		if err == nil {
+			log.Printf("Email %s already in use", *params.Email)
+			respondWithJSON(w, http.StatusConflict, returnError{Error: "Email already in use"})
+			return
+		}
+		if err.Error() != "sql: no rows in result set" {
+			log.Printf("Error getting user: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+	}
+	if params.Password != nil {
+		if !cfg.checkNewPassword(r.Context(), w, *params.Password, dbUser.Email, dbUser.Handle.String) {
+			return
+		}
+	}
+
+	var hash, token string
+	if params.Password != nil {
+		hash, err = cfg.Hasher.Hash(*params.Password)
+		if err != nil {
+			log.Printf("Error hashing password: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+	}
+	if params.Email != nil {
+		token, err = auth.MakeRefreshToken()
+		if err != nil {
+			log.Printf("Error creating confirmation token: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+	}
+
+	// Both changes are saved together, and the confirmation is only mailed
+	// once they are.
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	if params.Password != nil {
+		dbUser, err = qtx.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+			ID:             userID,
+			HashedPassword: hash,
+			UpdatedAt:      time.Now(),
+		})
+		if err != nil {
+			log.Printf("Error updating password: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+		err = qtx.RevokeRefreshTokensByUser(r.Context(), database.RevokeRefreshTokensByUserParams{
+			UserID:    userID,
+			RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			log.Printf("Error revoking refresh tokens: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+	}
+	if params.Email != nil {
+		// A later request replaces an earlier one.
+		err = qtx.UpsertEmailChange(r.Context(), database.UpsertEmailChangeParams{
+			UserID:    userID,
+			NewEmail:  *params.Email,
+			TokenHash: auth.HashToken(token),
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(emailChangeTTL),
+		})
+		if err != nil {
+			log.Printf("Error saving email change: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resUser := userFromDatabase(dbUser)
+	if params.Email != nil {
+		// The changes stand even if the mail fails; asking again sends a
+		// new token.
+		body := fmt.Sprintf("Use this token to confirm %s as the email address of your Chirpy account:\n\n%s\n\nIt expires in 24 hours. If you did not ask for this change, ignore this message.\n", *params.Email, token)
+		if err := cfg.Mailer.Send(r.Context(), *params.Email, "Confirm your new email address", body); err != nil {
+			log.Printf("Error sending confirmation email: %s", err)
+		}
+		resUser.PendingEmail = *params.Email
+	}
+	respondWithJSON(w, http.StatusOK, resUser)
+}
+
+// ConfirmEmail applies a pending email change given the token that was
+// mailed to the new address. The old address is told about the change.
+func (cfg *ApiConfig) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Token string `json:"token"`
+	}
+
 	decoder := json.NewDecoder(r.Body)
 	params := paramRequest{}
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	change, err := cfg.DbQueries.GetEmailChangeByToken(r.Context(), auth.HashToken(params.Token))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Email change not found: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid or expired token"})
+			return
+		}
+		log.Printf("Error getting email change: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if time.Now().After(change.ExpiresAt) {
+		log.Printf("Email change for user %s expired at %s", change.UserID, change.ExpiresAt)
+		if err := cfg.DbQueries.DeleteEmailChange(r.Context(), change.UserID); err != nil {
+			log.Printf("Error deleting email change: %s", err)
+		}
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid or expired token"})
+		return
+	}
+
+	oldUser, err := cfg.DbQueries.GetUserByID(r.Context(), change.UserID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	dbUser, err := qtx.UpdateUserEmail(r.Context(), database.UpdateUserEmailParams{
+		ID:        change.UserID,
+		Email:     change.NewEmail,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		if err.Error() == "pq: duplicate key value violates unique constraint \"users_email_key\"" {
+			log.Printf("Email already in use: %s", err)
+			respondWithJSON(w, http.StatusConflict, returnError{Error: "Email already in use"})
+			return
+		}
+		log.Printf("Error updating email: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err := qtx.DeleteEmailChange(r.Context(), change.UserID); err != nil {
+		log.Printf("Error deleting email change: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	body := fmt.Sprintf("The email address of your Chirpy account was changed to %s.\n", dbUser.Email)
+	if err := cfg.Mailer.Send(r.Context(), oldUser.Email, "Your email address was changed", body); err != nil {
+		log.Printf("Error sending email change notice: %s", err)
+	}
+
+	respondWithJSON(w, http.StatusOK, userFromDatabase(dbUser))
+}
+
+// ResetPassword sets a new password given the token mailed when an admin
+// forced a reset, letting the user log in again.
+func (cfg *ApiConfig) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
 	if err != nil {
 		log.Printf("Invalid JSON: %s", err)
 		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
 		return
 	}
 
-	hash, err := auth.HashPassword(params.Password)
+	reset, err := cfg.DbQueries.GetPasswordResetByToken(r.Context(), auth.HashToken(params.Token))
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Password reset not found: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid or expired token"})
+			return
+		}
+		log.Printf("Error getting password reset: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if time.Now().After(reset.ExpiresAt) {
+		log.Printf("Password reset for user %s expired at %s", reset.UserID, reset.ExpiresAt)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid or expired token"})
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), reset.UserID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if !cfg.checkNewPassword(r.Context(), w, params.Password, dbUser.Email, dbUser.Handle.String) {
+		return
+	}
+	hash, err := cfg.Hasher.Hash(params.Password)
 	if err != nil {
 		log.Printf("Error hashing password: %s", err)
 		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
 		return
 	}
 
-	dbUser, err := cfg.DbQueries.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:             userID,
-		Email:          params.Email,
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	err = qtx.ResetUserPassword(r.Context(), database.ResetUserPasswordParams{
+		ID:             dbUser.ID,
 		HashedPassword: hash,
 		UpdatedAt:      time.Now(),
 	})
 	if err != nil {
-		log.Printf("Error updating user: %s", err)
+		log.Printf("Error resetting password: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if err := qtx.DeletePasswordReset(r.Context(), dbUser.ID); err != nil {
+		log.Printf("Error deleting password reset: %s", err)
 		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
 		return
 	}
 
-	resUser := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
 	}
-	respondWithJSON(w, http.StatusOK, resUser)
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (cfg *ApiConfig) UpdateUserRed(w http.ResponseWriter, r *http.Request) {
@@ -262,3 +712,114 @@ func (cfg *ApiConfig) UpdateUserRed(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// DeleteUser moves the account to the trash after the user confirms their
+// password. Their chirps go with it, together with the rechirps of them, and
+// their sessions, OAuth grants and API keys are revoked. Everything is purged
+// once the trash expires.
+func (cfg *ApiConfig) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Password string `json:"password"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if err := auth.CheckPasswordHash(dbUser.HashedPassword, params.Password); err != nil {
+		log.Printf("Invalid password: %s", err)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Invalid password"})
+		return
+	}
+
+	tx, err := cfg.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.DbQueries.WithTx(tx)
+
+	deletedAt := sql.NullTime{Time: time.Now(), Valid: true}
+	count, err := qtx.SoftDeleteUser(r.Context(), database.SoftDeleteUserParams{
+		ID:        dbUser.ID,
+		DeletedAt: deletedAt,
+	})
+	if err != nil {
+		log.Printf("Error deleting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if count == 0 {
+		log.Printf("User %s was deleted concurrently", dbUser.ID)
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+		return
+	}
+
+	dbChirps, err := qtx.SoftDeleteChirpsByUser(r.Context(), database.SoftDeleteChirpsByUserParams{
+		DeletedAt: deletedAt,
+		UserID:    dbUser.ID,
+	})
+	if err != nil {
+		log.Printf("Error deleting chirps: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	// Streams drop the chirps too, but only those they were ever sent.
+	for _, dbChirp := range dbChirps {
+		if dbChirp.Status != chirpPublished {
+			continue
+		}
+		_, err = qtx.CreateChirpEvent(r.Context(), database.CreateChirpEventParams{
+			CreatedAt: deletedAt.Time,
+			EventType: pubsub.ChirpDeleted,
+			ChirpID:   dbChirp.ID,
+			UserID:    dbChirp.UserID.UUID,
+		})
+		if err != nil {
+			log.Printf("Error creating chirp event: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+	}
+
+	err = revokeAllSessions(r.Context(), qtx, dbUser.ID)
+	if err != nil {
+		log.Printf("Error revoking sessions: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		log.Printf("Error committing transaction: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,436 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/webauthn"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ceremonyRegister     = "register"
+	ceremonyLogin        = "login"
+	maxPasskeyNameLength = 64
+	// maxPendingChallenges bounds the challenges of one ceremony waiting
+	// for an answer, so unauthenticated logins cannot fill the table.
+	maxPendingChallenges = 10000
+	// fakeCredentialIDLength is the length of the credential IDs made up
+	// for addresses without passkeys.
+	fakeCredentialIDLength = 32
+)
+
+type Passkey struct {
+	ID         webauthn.Bytes `json:"id"`
+	Name       string         `json:"name"`
+	CreatedAt  time.Time      `json:"created_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+}
+
+func passkeyFromDatabase(dbCred database.WebauthnCredential) Passkey {
+	return Passkey{
+		ID:         dbCred.ID,
+		Name:       dbCred.Name,
+		CreatedAt:  dbCred.CreatedAt,
+		LastUsedAt: nullTimePtr(dbCred.LastUsedAt),
+	}
+}
+
+func (cfg *ApiConfig) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusNotFound, returnError{Error: "User not found"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	dbCreds, err := cfg.DbQueries.GetWebAuthnCredentialsByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting passkeys: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	exclude := make([][]byte, len(dbCreds))
+	for i, dbCred := range dbCreds {
+		exclude[i] = dbCred.ID
+	}
+
+	challenge, ok := cfg.newWebAuthnChallenge(w, r, ceremonyRegister, uuid.NullUUID{UUID: userID, Valid: true})
+	if !ok {
+		return
+	}
+
+	displayName := dbUser.DisplayName
+	if displayName == "" {
+		displayName = dbUser.Email
+	}
+	respondWithJSON(w, http.StatusOK, cfg.WebAuthn.CreationOptions(challenge, webauthn.User{
+		ID:          userID[:],
+		Name:        dbUser.Email,
+		DisplayName: displayName,
+	}, exclude))
+}
+
+func (cfg *ApiConfig) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		webauthn.AttestationResponse
+		Name string `json:"name"`
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := paramRequest{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	if params.Name == "" {
+		params.Name = "Passkey"
+	}
+	if len([]rune(params.Name)) > maxPasskeyNameLength {
+		log.Printf("Passkey name too long: %d", len([]rune(params.Name)))
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Passkey name is too long"})
+		return
+	}
+
+	challenge, err := params.Challenge()
+	if err != nil {
+		log.Printf("Invalid passkey response: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid passkey response"})
+		return
+	}
+	dbChallenge, ok := cfg.takeWebAuthnChallenge(w, r, challenge, ceremonyRegister)
+	if !ok {
+		return
+	}
+	if dbChallenge.UserID.UUID != userID {
+		log.Printf("Registration challenge of user %s answered by %s", dbChallenge.UserID.UUID, userID)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Unknown or expired challenge"})
+		return
+	}
+
+	cred, err := cfg.WebAuthn.VerifyRegistration(&params.AttestationResponse, challenge, false)
+	if err != nil {
+		log.Printf("Error verifying passkey registration: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid passkey response"})
+		return
+	}
+
+	dbCred, err := cfg.DbQueries.CreateWebAuthnCredential(r.Context(), database.CreateWebAuthnCredentialParams{
+		ID:        cred.ID,
+		UserID:    userID,
+		PublicKey: cred.PublicKey,
+		SignCount: int64(cred.SignCount),
+		Name:      params.Name,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Passkey already registered: %s", err)
+			respondWithJSON(w, http.StatusConflict, returnError{Error: "Passkey already registered"})
+			return
+		}
+		log.Printf("Error saving passkey: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, passkeyFromDatabase(dbCred))
+}
+
+// fakeCredentialIDs makes up a credential list for an address without
+// passkeys. It stays the same across requests, so BeginPasskeyLogin gives
+// away neither which addresses have passkeys nor which have accounts.
+func (cfg *ApiConfig) fakeCredentialIDs(email string) [][]byte {
+	mac := hmac.New(sha256.New, []byte(cfg.JwtSecret))
+	mac.Write([]byte("passkey login\x00" + strings.ToLower(email)))
+	return [][]byte{mac.Sum(nil)[:fakeCredentialIDLength]}
+}
+
+// BeginPasskeyLogin starts a login. Given an email address, the browser is
+// told which credentials of that user to offer; without one it offers the
+// passkeys it has for the site.
+func (cfg *ApiConfig) BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	type paramRequest struct {
+		Email string `json:"email"`
+	}
+
+	params := paramRequest{}
+	if r.ContentLength != 0 {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&params)
+		if err != nil {
+			log.Printf("Invalid JSON: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+			return
+		}
+	}
+
+	var allow [][]byte
+	userID := uuid.NullUUID{}
+	if params.Email != "" {
+		// Unknown addresses still get a challenge, which any
+		// discoverable passkey can answer, and a made-up credential list
+		// like that of an address with passkeys.
+		dbUser, err := cfg.DbQueries.GetUser(r.Context(), params.Email)
+		if err != nil && err.Error() != "sql: no rows in result set" {
+			log.Printf("Error getting user: %s", err)
+			respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+			return
+		}
+		if err == nil {
+			dbCreds, err := cfg.DbQueries.GetWebAuthnCredentialsByUser(r.Context(), dbUser.ID)
+			if err != nil {
+				log.Printf("Error getting passkeys: %s", err)
+				respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+				return
+			}
+			for _, dbCred := range dbCreds {
+				allow = append(allow, dbCred.ID)
+			}
+			userID = uuid.NullUUID{UUID: dbUser.ID, Valid: true}
+		}
+		if len(allow) == 0 {
+			allow = cfg.fakeCredentialIDs(params.Email)
+		}
+	}
+
+	challenge, ok := cfg.newWebAuthnChallenge(w, r, ceremonyLogin, userID)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, cfg.WebAuthn.RequestOptions(challenge, allow))
+}
+
+// FinishPasskeyLogin logs in with a passkey assertion. A passkey that
+// verified the user counts as both factors; one that did not stands in for
+// the password, and users with two-factor authentication still get an MFA
+// challenge.
+func (cfg *ApiConfig) FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	params := webauthn.AssertionResponse{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		log.Printf("Invalid JSON: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid JSON"})
+		return
+	}
+
+	challenge, err := params.Challenge()
+	if err != nil {
+		log.Printf("Invalid passkey response: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid passkey response"})
+		return
+	}
+	dbChallenge, ok := cfg.takeWebAuthnChallenge(w, r, challenge, ceremonyLogin)
+	if !ok {
+		return
+	}
+
+	dbCred, err := cfg.DbQueries.GetWebAuthnCredential(r.Context(), params.RawID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Passkey not found: %s", err)
+			respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+			return
+		}
+		log.Printf("Error getting passkey: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if dbChallenge.UserID.Valid && dbChallenge.UserID.UUID != dbCred.UserID {
+		log.Printf("Login challenge of user %s answered with a passkey of %s", dbChallenge.UserID.UUID, dbCred.UserID)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return
+	}
+	if len(params.Response.UserHandle) > 0 && !bytes.Equal(params.Response.UserHandle, dbCred.UserID[:]) {
+		log.Printf("Passkey user handle does not match user %s", dbCred.UserID)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return
+	}
+
+	result, err := cfg.WebAuthn.VerifyAssertion(&params, challenge, webauthn.Credential{
+		ID:        dbCred.ID,
+		PublicKey: dbCred.PublicKey,
+		SignCount: uint32(dbCred.SignCount),
+	}, false)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCount) {
+			log.Printf("Passkey %x of user %s may be cloned: %s", dbCred.ID, dbCred.UserID, err)
+		} else {
+			log.Printf("Error verifying passkey assertion: %s", err)
+		}
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return
+	}
+
+	// The counter only moves forward, so of two logins racing with the
+	// same count one fails.
+	rows, err := cfg.DbQueries.UpdateWebAuthnSignCount(r.Context(), database.UpdateWebAuthnSignCountParams{
+		SignCount:    int64(result.SignCount),
+		LastUsedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+		ID:           dbCred.ID,
+		OldSignCount: dbCred.SignCount,
+	})
+	if err != nil {
+		log.Printf("Error updating passkey: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		log.Printf("Passkey %x was used concurrently", dbCred.ID)
+		respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+		return
+	}
+
+	dbUser, err := cfg.DbQueries.GetUserByID(r.Context(), dbCred.UserID)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("User not found: %s", err)
+			respondWithJSON(w, http.StatusUnauthorized, returnError{Error: "Unauthorized"})
+			return
+		}
+		log.Printf("Error getting user: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	if result.UserVerified {
+		cfg.startSession(w, r, dbUser)
+		return
+	}
+	cfg.completeLogin(w, r, dbUser)
+}
+
+func (cfg *ApiConfig) GetPasskeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	dbCreds, err := cfg.DbQueries.GetWebAuthnCredentialsByUser(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting passkeys: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+
+	resPasskeys := make([]Passkey, len(dbCreds))
+	for i, dbCred := range dbCreds {
+		resPasskeys[i] = passkeyFromDatabase(dbCred)
+	}
+	respondWithJSON(w, http.StatusOK, resPasskeys)
+}
+
+func (cfg *ApiConfig) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	credentialID, err := base64.RawURLEncoding.DecodeString(r.PathValue("credentialID"))
+	if err != nil {
+		log.Printf("Invalid credentialID: %s", err)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Invalid passkey ID"})
+		return
+	}
+
+	userID, ok := cfg.authenticate(w, r, "")
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.DbQueries.DeleteWebAuthnCredential(r.Context(), database.DeleteWebAuthnCredentialParams{
+		ID:     credentialID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error deleting passkey: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return
+	}
+	if rows == 0 {
+		log.Printf("Passkey %x of user %s not found", credentialID, userID)
+		respondWithJSON(w, http.StatusNotFound, returnError{Error: "Passkey not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *ApiConfig) newWebAuthnChallenge(w http.ResponseWriter, r *http.Request, ceremony string, userID uuid.NullUUID) ([]byte, bool) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		log.Printf("Error creating challenge: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return nil, false
+	}
+
+	rows, err := cfg.DbQueries.CreateWebAuthnChallenge(r.Context(), database.CreateWebAuthnChallengeParams{
+		Challenge:  challenge,
+		Ceremony:   ceremony,
+		UserID:     userID,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(cfg.WebAuthn.Timeout),
+		MaxPending: maxPendingChallenges,
+	})
+	if err != nil {
+		log.Printf("Error saving challenge: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return nil, false
+	}
+	if rows == 0 {
+		log.Printf("Too many pending %s challenges", ceremony)
+		respondWithJSON(w, http.StatusTooManyRequests, returnError{Error: "Too many pending requests, try again later"})
+		return nil, false
+	}
+	return challenge, true
+}
+
+// takeWebAuthnChallenge uses up a challenge, so that each response is only
+// accepted once.
+func (cfg *ApiConfig) takeWebAuthnChallenge(w http.ResponseWriter, r *http.Request, challenge []byte, ceremony string) (database.WebauthnChallenge, bool) {
+	dbChallenge, err := cfg.DbQueries.TakeWebAuthnChallenge(r.Context(), database.TakeWebAuthnChallengeParams{
+		Challenge: challenge,
+		Ceremony:  ceremony,
+	})
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			log.Printf("Challenge not found: %s", err)
+			respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Unknown or expired challenge"})
+			return database.WebauthnChallenge{}, false
+		}
+		log.Printf("Error getting challenge: %s", err)
+		respondWithJSON(w, http.StatusInternalServerError, returnError{Error: "Internal Server Error"})
+		return database.WebauthnChallenge{}, false
+	}
+	if time.Now().After(dbChallenge.ExpiresAt) {
+		log.Printf("Challenge expired at %s", dbChallenge.ExpiresAt)
+		respondWithJSON(w, http.StatusBadRequest, returnError{Error: "Unknown or expired challenge"})
+		return database.WebauthnChallenge{}, false
+	}
+	return dbChallenge, true
+}
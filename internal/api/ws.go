@@ -0,0 +1,262 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/websocket"
+
+	"github.com/google/uuid"
+)
+
+const (
+	wsMaxConnsPerUser = 5
+	wsSendBufferSize  = 64
+	wsMaxMessageSize  = 4096
+	wsPongWait        = 60 * time.Second
+	wsPingPeriod      = 50 * time.Second
+)
+
+// Topics a WebSocket client can subscribe to. A user's chirps are
+// addressed as "user:<user ID>".
+const (
+	wsTopicFeed     = "feed"
+	wsTopicMentions = "mentions"
+	wsTopicUser     = "user:"
+)
+
+type wsRequest struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+}
+
+type wsResponse struct {
+	Type  string      `json:"type"`
+	Topic string      `json:"topic,omitempty"`
+	Event string      `json:"event,omitempty"`
+	ID    int64       `json:"id,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+type wsClient struct {
+	userID uuid.UUID
+	mu     sync.Mutex
+	topics map[string]bool
+}
+
+func validTopic(topic string) bool {
+	if topic == wsTopicFeed || topic == wsTopicMentions {
+		return true
+	}
+	if strings.HasPrefix(topic, wsTopicUser) {
+		_, err := uuid.Parse(strings.TrimPrefix(topic, wsTopicUser))
+		return err == nil
+	}
+	return false
+}
+
+// matchingTopics returns the subscribed topics the event belongs to.
+func (c *wsClient) matchingTopics(e pubsub.Event) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	topics := make([]string, 0)
+	if c.topics[wsTopicFeed] {
+		topics = append(topics, wsTopicFeed)
+	}
+	if c.topics[wsTopicUser+e.UserID.String()] {
+		topics = append(topics, wsTopicUser+e.UserID.String())
+	}
+	if c.topics[wsTopicMentions] && e.Type == pubsub.ChirpCreated {
+		for _, mentioned := range e.Mentions {
+			if mentioned == c.userID {
+				topics = append(topics, wsTopicMentions)
+				break
+			}
+		}
+	}
+	return topics
+}
+
+func (cfg *ApiConfig) acquireWsConn(userID uuid.UUID) bool {
+	cfg.wsMu.Lock()
+	defer cfg.wsMu.Unlock()
+
+	if cfg.wsConns == nil {
+		cfg.wsConns = make(map[uuid.UUID]int)
+	}
+	if cfg.wsConns[userID] >= wsMaxConnsPerUser {
+		return false
+	}
+	cfg.wsConns[userID]++
+	return true
+}
+
+func (cfg *ApiConfig) releaseWsConn(userID uuid.UUID) {
+	cfg.wsMu.Lock()
+	defer cfg.wsMu.Unlock()
+
+	cfg.wsConns[userID]--
+	if cfg.wsConns[userID] <= 0 {
+		delete(cfg.wsConns, userID)
+	}
+}
+
+func writeWsResponse(conn *websocket.Conn, res wsResponse) error {
+	dat, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, dat)
+}
+
+func (cfg *ApiConfig) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Browsers send cookies and query strings on cross-site WebSocket
+	// requests without asking, so other sites are turned away.
+	if origin := r.Header.Get("Origin"); origin != "" && !slices.Contains(cfg.WsOrigins, origin) {
+		log.Printf("WebSocket from disallowed origin %s", origin)
+		respondWithJSON(w, http.StatusForbidden, returnError{Error: "Origin not allowed"})
+		return
+	}
+
+	// Browsers cannot set headers on WebSocket requests, so the access token
+	// may also be passed as a query parameter.
+	var c caller
+	var err error
+	if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+		c, err = cfg.identifyToken(r.Context(), token)
+	} else {
+		c, err = cfg.identify(r)
+	}
+	userID, ok := authorize(w, c, err, auth.ScopeChirpsRead)
+	if !ok {
+		return
+	}
+
+	if !cfg.acquireWsConn(userID) {
+		log.Printf("Too many WebSocket connections for user %s", userID)
+		respondWithJSON(w, http.StatusTooManyRequests, returnError{Error: "Too many connections"})
+		return
+	}
+	defer cfg.releaseWsConn(userID)
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		log.Printf("Error upgrading to WebSocket: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	client := &wsClient{userID: userID, topics: make(map[string]bool)}
+	sub := cfg.Broker.Subscribe(wsSendBufferSize, func(e pubsub.Event) bool {
+		return len(client.matchingTopics(e)) > 0
+	})
+	defer cfg.Broker.Unsubscribe(sub)
+
+	send := make(chan wsResponse, wsSendBufferSize)
+	done := make(chan struct{})
+	defer close(done)
+	go wsWriter(conn, client, sub, send, done)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func() {
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, dat, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		req := wsRequest{}
+		res := wsResponse{}
+		if err := json.Unmarshal(dat, &req); err != nil {
+			res = wsResponse{Type: "error", Error: "Invalid JSON"}
+		} else if !validTopic(req.Topic) {
+			res = wsResponse{Type: "error", Topic: req.Topic, Error: "Invalid topic"}
+		} else {
+			switch req.Type {
+			case "subscribe":
+				client.mu.Lock()
+				client.topics[req.Topic] = true
+				client.mu.Unlock()
+				res = wsResponse{Type: "subscribed", Topic: req.Topic}
+			case "unsubscribe":
+				client.mu.Lock()
+				delete(client.topics, req.Topic)
+				client.mu.Unlock()
+				res = wsResponse{Type: "unsubscribed", Topic: req.Topic}
+			default:
+				res = wsResponse{Type: "error", Error: "Invalid message type"}
+			}
+		}
+
+		select {
+		case send <- res:
+		default:
+			log.Printf("Closing WebSocket for slow client %s", userID)
+			conn.WriteClose(websocket.CloseTryAgainLater, "slow consumer")
+			return
+		}
+	}
+}
+
+// wsWriter owns all writes to the connection apart from control frames
+// sent while reading. It closes the connection when it stops, which also
+// unblocks the reader.
+func wsWriter(conn *websocket.Conn, client *wsClient, sub *pubsub.Subscription, send <-chan wsResponse, done <-chan struct{}) {
+	defer conn.Close()
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case res := <-send:
+			if err := writeWsResponse(conn, res); err != nil {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				if sub.Lagged() {
+					log.Printf("Closing WebSocket for slow client %s", client.userID)
+					conn.WriteClose(websocket.CloseTryAgainLater, "slow consumer")
+				}
+				return
+			}
+			for _, topic := range client.matchingTopics(e) {
+				res := wsResponse{
+					Type:  "event",
+					Topic: topic,
+					Event: e.Type,
+					ID:    e.ID,
+					Data:  chirpEventPayload(e),
+				}
+				if topic == wsTopicMentions {
+					res.Type = "notification"
+					res.Event = "mention"
+				}
+				if err := writeWsResponse(conn, res); err != nil {
+					return
+				}
+			}
+		case <-ping.C:
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachChecker reports whether a password is known from a data breach.
+type BreachChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+// RangeDir checks passwords against a local copy of Have I Been Pwned style
+// range files. Each file is named after the first five hex digits of the
+// SHA-1 of a password, optionally with a .txt extension, and holds
+// SUFFIX:COUNT lines for the hashes sharing that prefix. This is the format
+// the online range API returns, so a checker using it reads the same lines.
+type RangeDir struct {
+	Dir string
+	// MinCount is how often a password must have been seen to be refused.
+	MinCount int
+	// Prefixes is how many range files NewRangeDir found. Passwords whose
+	// range file is missing are never refused, so a copy with fewer than
+	// RangePrefixes files lets some breached passwords through.
+	Prefixes int
+}
+
+// RangePrefixes is the number of range files in a complete copy.
+const RangePrefixes = 1 << 20
+
+// NewRangeDir opens a directory of range files. It fails when there are
+// none, as every check would then pass.
+func NewRangeDir(dir string, minCount int) (*RangeDir, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	prefixes := 0
+	for {
+		entries, err := f.ReadDir(4096)
+		for _, entry := range entries {
+			if !entry.IsDir() && isRangeFileName(entry.Name()) {
+				prefixes++
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if prefixes == 0 {
+		return nil, fmt.Errorf("no range files in %s", dir)
+	}
+	return &RangeDir{Dir: dir, MinCount: max(minCount, 1), Prefixes: prefixes}, nil
+}
+
+// isRangeFileName reports whether name is five hex digits, optionally
+// followed by .txt.
+func isRangeFileName(name string) bool {
+	name = strings.TrimSuffix(name, ".txt")
+	if len(name) != 5 {
+		return false
+	}
+	_, err := hex.DecodeString(name + "0")
+	return err == nil
+}
+
+func (d *RangeDir) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	f, err := os.Open(filepath.Join(d.Dir, prefix))
+	if errors.Is(err, fs.ErrNotExist) {
+		f, err = os.Open(filepath.Join(d.Dir, prefix+".txt"))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	count, err := rangeCount(f, suffix)
+	if err != nil {
+		return false, err
+	}
+	return count >= d.MinCount, nil
+}
+
+// rangeCount finds suffix in a range listing and returns how often it was
+// seen. Padding entries, which have a count of zero, never match.
+func rangeCount(r io.Reader, suffix string) (int, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		hash, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok || !strings.EqualFold(hash, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("invalid count for %s: %w", hash, err)
+		}
+		return n, nil
+	}
+	return 0, scanner.Err()
+}
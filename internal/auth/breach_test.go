@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRangeDir(t *testing.T) {
+	dir := t.TempDir()
+	// SHA-1("password") is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8 and
+	// SHA-1("letmein") is B7A875FC1EA228B9061041B7CEC4BD3C52AB3CE3.
+	err := os.WriteFile(filepath.Join(dir, "5BAA6"), []byte("003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "B7A87.txt"), []byte("5FC1EA228B9061041B7CEC4BD3C52AB3CE3:0\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checker, err := NewRangeDir(dir, 1)
+	if err != nil {
+		t.Fatalf(`NewRangeDir returned an error: %v`, err)
+	}
+	if checker.Prefixes != 2 {
+		t.Errorf(`Prefixes = %d, want 2`, checker.Prefixes)
+	}
+
+	cases := map[string]bool{
+		"password": true,
+		"letmein":  false, // padding entry
+		"kx7#pq2m": false, // no range file
+		"Password": false,
+	}
+	for password, want := range cases {
+		got, err := checker.IsBreached(context.Background(), password)
+		if err != nil {
+			t.Fatalf(`IsBreached(%q) returned an error: %v`, password, err)
+		}
+		if got != want {
+			t.Errorf(`IsBreached(%q) = %v, want %v`, password, got, want)
+		}
+	}
+
+	checker.MinCount = 10000000
+	if got, _ := checker.IsBreached(context.Background(), "password"); got {
+		t.Errorf(`IsBreached("password") = true below MinCount`)
+	}
+}
+
+func TestRangeDirEmpty(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("range files go here\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRangeDir(dir, 1); err == nil {
+		t.Errorf(`NewRangeDir accepted a directory without range files`)
+	}
+}
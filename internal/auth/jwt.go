@@ -1,20 +1,134 @@
 package auth
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// Access tokens and MFA challenge tokens are signed with the same secret,
+// so the issuer keeps one from being accepted as the other.
+const (
+	accessIssuer = "chirpy"
+	mfaIssuer    = "chirpy-mfa"
+)
+
+var ErrScopedToken = errors.New("token was issued to an OAuth client")
+
+// AccessToken is what an access token says about its bearer. Tokens from
+// logging in to Chirpy itself have no client and may do anything; tokens
+// issued to OAuth clients are limited to their scopes.
+type AccessToken struct {
+	UserID    uuid.UUID
+	ClientID  string
+	GrantID   uuid.UUID
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// HasScope reports whether the token allows scope.
+func (t AccessToken) HasScope(scope string) bool {
+	return t.ClientID == "" || slices.Contains(t.Scopes, scope)
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+}
+
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return makeJWT(claims{}, userID, tokenSecret, accessIssuer, expiresIn)
+}
+
+// ValidateJWT only accepts tokens from logging in to Chirpy itself; use
+// ParseAccessToken where OAuth clients are allowed.
+func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	token, err := ParseAccessToken(tokenString, tokenSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if token.ClientID != "" {
+		return uuid.Nil, ErrScopedToken
+	}
+	return token.UserID, nil
+}
+
+// MakeOAuthToken returns an access token for clientID to act on behalf of
+// userID within scopes. The grant ID lets the token be revoked before it
+// expires.
+func MakeOAuthToken(userID uuid.UUID, clientID string, grantID uuid.UUID, scopes []string, tokenSecret string, expiresIn time.Duration) (string, error) {
+	c := claims{ClientID: clientID, Scope: FormatScope(scopes)}
+	c.ID = grantID.String()
+	return makeJWT(c, userID, tokenSecret, accessIssuer, expiresIn)
+}
+
+// ParseAccessToken validates an access token of either kind.
+func ParseAccessToken(tokenString, tokenSecret string) (AccessToken, error) {
+	c, err := parseJWT(tokenString, tokenSecret, accessIssuer)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	token := AccessToken{
+		UserID:    userID,
+		ClientID:  c.ClientID,
+		IssuedAt:  c.IssuedAt.Time,
+		ExpiresAt: c.ExpiresAt.Time,
+	}
+	if c.ClientID != "" {
+		token.GrantID, err = uuid.Parse(c.ID)
+		if err != nil {
+			return AccessToken{}, err
+		}
+		token.Scopes = strings.Fields(c.Scope)
+	}
+	return token, nil
+}
+
+// MakeMFAToken returns a token proving that userID got their password
+// right, to be exchanged for an access token with a second factor. The
+// challenge ID lets the server count attempts made with the token and
+// refuse it once used.
+func MakeMFAToken(userID, challengeID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	c := claims{}
+	c.ID = challengeID.String()
+	return makeJWT(c, userID, tokenSecret, mfaIssuer, expiresIn)
+}
+
+// ValidateMFAToken returns the user and challenge IDs of an MFA token.
+func ValidateMFAToken(tokenString, tokenSecret string) (userID, challengeID uuid.UUID, err error) {
+	c, err := parseJWT(tokenString, tokenSecret, mfaIssuer)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	userID, err = uuid.Parse(c.Subject)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	challengeID, err = uuid.Parse(c.ID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return userID, challengeID, nil
+}
+
+func makeJWT(c claims, userID uuid.UUID, tokenSecret, issuer string, expiresIn time.Duration) (string, error) {
+	c.Issuer = issuer
+	c.Subject = userID.String()
+	c.IssuedAt = jwt.NewNumericDate(time.Now())
+	c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(expiresIn))
+
 	// Create a new JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		Subject:   userID.String(),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-	})
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
 
 	// Sign the token with the secret
 	tokenString, err := token.SignedString([]byte(tokenSecret))
@@ -25,29 +139,19 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return tokenString, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+func parseJWT(tokenString, tokenSecret, issuer string) (*claims, error) {
 	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(tokenSecret), nil
-	})
+	}, jwt.WithIssuer(issuer), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
 
 	// Check the claims
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
-		// Check if the token is expired
-		if claims.ExpiresAt.Time.Before(time.Now()) {
-			return uuid.Nil, jwt.ErrTokenExpired
-		}
-		// Convert the subject to a UUID
-		userId, err := uuid.Parse(claims.Subject)
-		if err != nil {
-			return uuid.Nil, err
-		}
-		// Return the user ID
-		return userId, nil
+	c, ok := token.Claims.(*claims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenSignatureInvalid
 	}
-
-	return uuid.Nil, jwt.ErrTokenSignatureInvalid
+	return c, nil
 }
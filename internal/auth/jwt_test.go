@@ -25,3 +25,66 @@ func TestJWT(t *testing.T) {
 		t.Errorf(`ValidateJWT(%q, %q) = %q, want %q`, token, secret, userID, "abc123")
 	}
 }
+
+func TestMFATokenIsNotAnAccessToken(t *testing.T) {
+	userID := uuid.New()
+	challengeID := uuid.New()
+	secret := "aksjf qw e83947 5y3947987t 5(*&*90 7gq9-v8rhu)"
+
+	mfaToken, err := MakeMFAToken(userID, challengeID, secret, 5*time.Minute)
+	if err != nil {
+		t.Fatalf(`MakeMFAToken returned an error: %v`, err)
+	}
+	if _, err := ValidateJWT(mfaToken, secret); err == nil {
+		t.Errorf(`ValidateJWT accepted an MFA token`)
+	}
+	if tokenID, tokenChallengeID, err := ValidateMFAToken(mfaToken, secret); err != nil || tokenID != userID || tokenChallengeID != challengeID {
+		t.Errorf(`ValidateMFAToken = %q, %q, %v, want %q, %q`, tokenID, tokenChallengeID, err, userID, challengeID)
+	}
+
+	accessToken, err := MakeJWT(userID, secret, 5*time.Minute)
+	if err != nil {
+		t.Fatalf(`MakeJWT returned an error: %v`, err)
+	}
+	if _, _, err := ValidateMFAToken(accessToken, secret); err == nil {
+		t.Errorf(`ValidateMFAToken accepted an access token`)
+	}
+}
+
+func TestOAuthToken(t *testing.T) {
+	userID := uuid.New()
+	grantID := uuid.New()
+	secret := "aksjf qw e83947 5y3947987t 5(*&*90 7gq9-v8rhu)"
+	scopes := []string{ScopeChirpsRead, ScopeProfile}
+
+	token, err := MakeOAuthToken(userID, "client", grantID, scopes, secret, 5*time.Minute)
+	if err != nil {
+		t.Fatalf(`MakeOAuthToken returned an error: %v`, err)
+	}
+	if _, err := ValidateJWT(token, secret); err == nil {
+		t.Errorf(`ValidateJWT accepted an OAuth token`)
+	}
+
+	got, err := ParseAccessToken(token, secret)
+	if err != nil {
+		t.Fatalf(`ParseAccessToken returned an error: %v`, err)
+	}
+	if got.UserID != userID || got.ClientID != "client" || got.GrantID != grantID {
+		t.Errorf(`ParseAccessToken = %+v, want user %q, client "client", grant %q`, got, userID, grantID)
+	}
+	if !got.HasScope(ScopeChirpsRead) || !got.HasScope(ScopeProfile) || got.HasScope(ScopeChirpsWrite) {
+		t.Errorf(`ParseAccessToken scopes = %q, want %q`, got.Scopes, scopes)
+	}
+
+	accessToken, err := MakeJWT(userID, secret, 5*time.Minute)
+	if err != nil {
+		t.Fatalf(`MakeJWT returned an error: %v`, err)
+	}
+	got, err = ParseAccessToken(accessToken, secret)
+	if err != nil {
+		t.Fatalf(`ParseAccessToken returned an error: %v`, err)
+	}
+	if got.ClientID != "" || !got.HasScope(ScopeChirpsWrite) {
+		t.Errorf(`ParseAccessToken(first-party token) = %+v, want an unrestricted token`, got)
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Scopes an OAuth client can ask for.
+const (
+	ScopeChirpsRead  = "chirps:read"
+	ScopeChirpsWrite = "chirps:write"
+	ScopeProfile     = "profile"
+)
+
+// Scopes lists every scope in the order they are shown to users.
+var Scopes = []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeProfile}
+
+var ErrInvalidScope = errors.New("invalid scope")
+
+// ParseScope reads a space separated scope parameter. Unknown scopes are
+// an error; duplicates are dropped and the rest sorted like Scopes.
+func ParseScope(scope string) ([]string, error) {
+	fields := strings.Fields(scope)
+	if len(fields) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, s := range fields {
+		if !slices.Contains(Scopes, s) {
+			return nil, ErrInvalidScope
+		}
+	}
+	var scopes []string
+	for _, s := range Scopes {
+		if slices.Contains(fields, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes, nil
+}
+
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// ValidPKCEVerifier checks a code verifier against RFC 7636: 43 to 128
+// unreserved characters.
+func ValidPKCEVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// PKCEChallenge returns the S256 code challenge for verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE reports whether verifier matches an S256 code challenge.
+func VerifyPKCE(verifier, challenge string) bool {
+	if !ValidPKCEVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
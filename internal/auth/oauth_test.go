@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScope(t *testing.T) {
+	cases := []struct {
+		scope string
+		want  []string
+		err   bool
+	}{
+		{scope: "chirps:read", want: []string{ScopeChirpsRead}},
+		{scope: "profile chirps:write chirps:read profile", want: []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeProfile}},
+		{scope: "", err: true},
+		{scope: "chirps:read admin", err: true},
+	}
+	for _, c := range cases {
+		got, err := ParseScope(c.scope)
+		if c.err {
+			if err == nil {
+				t.Errorf(`ParseScope(%q) = %q, want an error`, c.scope, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf(`ParseScope(%q) = %q, %v, want %q`, c.scope, got, err, c.want)
+		}
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	// From RFC 7636, appendix B.
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if got := PKCEChallenge(verifier); got != challenge {
+		t.Errorf(`PKCEChallenge(%q) = %q, want %q`, verifier, got, challenge)
+	}
+	if !VerifyPKCE(verifier, challenge) {
+		t.Errorf(`VerifyPKCE(%q, %q) = false, want true`, verifier, challenge)
+	}
+	if VerifyPKCE(strings.ToUpper(verifier), challenge) {
+		t.Errorf(`VerifyPKCE accepted the wrong verifier`)
+	}
+	if VerifyPKCE("short", PKCEChallenge("short")) {
+		t.Errorf(`VerifyPKCE accepted a verifier shorter than 43 characters`)
+	}
+	if VerifyPKCE(verifier+"!", PKCEChallenge(verifier+"!")) {
+		t.Errorf(`VerifyPKCE accepted a verifier with a reserved character`)
+	}
+}
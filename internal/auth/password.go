@@ -1,12 +1,175 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrMismatchedPassword = errors.New("password does not match hash")
+	ErrUnsupportedHash    = errors.New("unsupported password hash format")
+)
+
+// PasswordHasher turns passwords into self-describing hash strings. Verify
+// only needs the hash, so hashes made with older parameters keep working;
+// NeedsRehash tells when such a hash should be replaced.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) error
+	NeedsRehash(hash string) bool
+}
+
+// DefaultHasher is used by HashPassword for new hashes.
+var DefaultHasher PasswordHasher = DefaultArgon2id
 
 func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hash), err
+	return DefaultHasher.Hash(password)
 }
 
+// CheckPasswordHash verifies password against a hash made by any of the
+// supported hashers.
 func CheckPasswordHash(hash, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return Argon2idHasher{}.Verify(hash, password)
+	case strings.HasPrefix(hash, "$2"):
+		return BcryptHasher{}.Verify(hash, password)
+	default:
+		return ErrUnsupportedHash
+	}
+}
+
+// Argon2idHasher hashes passwords with Argon2id into PHC strings such as
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>. Memory is in KiB.
+type Argon2idHasher struct {
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2id follows the OWASP recommendation of 19 MiB of memory and
+// two passes.
+var DefaultArgon2id = Argon2idHasher{
+	Memory:      19 * 1024,
+	Time:        2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// ParseArgon2idParams reads parameters in the PHC form m=...,t=...,p=...
+// on top of DefaultArgon2id.
+func ParseArgon2idParams(s string) (Argon2idHasher, error) {
+	h := DefaultArgon2id
+	if _, err := fmt.Sscanf(s, "m=%d,t=%d,p=%d", &h.Memory, &h.Time, &h.Parallelism); err != nil {
+		return Argon2idHasher{}, fmt.Errorf("invalid argon2id parameters %q: %w", s, err)
+	}
+	if h.Memory < 8*uint32(h.Parallelism) || h.Time < 1 || h.Parallelism < 1 {
+		return Argon2idHasher{}, fmt.Errorf("invalid argon2id parameters %q", s)
+	}
+	return h, nil
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Time, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify uses the parameters stored in hash, not those of h.
+func (h Argon2idHasher) Verify(hash, password string) error {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory ||
+		params.Time != h.Time ||
+		params.Parallelism != h.Parallelism ||
+		uint32(len(salt)) != h.SaltLength ||
+		uint32(len(key)) != h.KeyLength
+}
+
+func decodeArgon2id(hash string) (params Argon2idHasher, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	if params.Time < 1 || params.Parallelism < 1 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+	return params, salt, key, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt. bcrypt only looks at the first
+// 72 bytes, so Hash refuses longer passwords rather than truncating them.
+type BcryptHasher struct {
+	Cost int
+}
+
+var DefaultBcrypt = BcryptHasher{Cost: bcrypt.DefaultCost}
+
+func (h BcryptHasher) MaxPasswordBytes() int {
+	return 72
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h BcryptHasher) Verify(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatchedPassword
+	}
+	return err
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
 }
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -17,3 +19,112 @@ func TestPassword(t *testing.T) {
 		t.Fatalf(`CheckPasswordHash(%q, %q) returned an error: %v`, hash, password, err)
 	}
 }
+
+func TestPasswordMismatch(t *testing.T) {
+	hash, err := HashPassword("abc123")
+	if err != nil {
+		t.Fatalf(`HashPassword returned an error: %v`, err)
+	}
+	if err := CheckPasswordHash(hash, "abc124"); !errors.Is(err, ErrMismatchedPassword) {
+		t.Errorf(`CheckPasswordHash with the wrong password = %v, want ErrMismatchedPassword`, err)
+	}
+}
+
+func TestArgon2idHash(t *testing.T) {
+	h := Argon2idHasher{Memory: 64, Time: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf(`Hash returned an error: %v`, err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf(`Hash = %q, want a PHC argon2id string`, hash)
+	}
+	if err := CheckPasswordHash(hash, "correct horse"); err != nil {
+		t.Errorf(`CheckPasswordHash(%q) returned an error: %v`, hash, err)
+	}
+	if h.NeedsRehash(hash) {
+		t.Errorf(`NeedsRehash(%q) = true with the same parameters`, hash)
+	}
+
+	stronger := h
+	stronger.Time = 2
+	if !stronger.NeedsRehash(hash) {
+		t.Errorf(`NeedsRehash(%q) = false with more passes`, hash)
+	}
+}
+
+func TestBcryptHashIsRehashed(t *testing.T) {
+	hash, err := BcryptHasher{Cost: 4}.Hash("abc123")
+	if err != nil {
+		t.Fatalf(`Hash returned an error: %v`, err)
+	}
+	if err := CheckPasswordHash(hash, "abc123"); err != nil {
+		t.Errorf(`CheckPasswordHash(%q) returned an error: %v`, hash, err)
+	}
+	if !DefaultArgon2id.NeedsRehash(hash) {
+		t.Errorf(`Argon2id NeedsRehash(%q) = false for a bcrypt hash`, hash)
+	}
+	if (BcryptHasher{Cost: 4}).NeedsRehash(hash) {
+		t.Errorf(`bcrypt NeedsRehash(%q) = true with the same cost`, hash)
+	}
+
+	if _, err := (BcryptHasher{Cost: 4}).Hash(strings.Repeat("a", 73)); err == nil {
+		t.Errorf(`bcrypt Hash accepted a password longer than 72 bytes`)
+	}
+}
+
+func TestParseArgon2idParams(t *testing.T) {
+	h, err := ParseArgon2idParams("m=65536,t=3,p=4")
+	if err != nil {
+		t.Fatalf(`ParseArgon2idParams returned an error: %v`, err)
+	}
+	if h.Memory != 65536 || h.Time != 3 || h.Parallelism != 4 || h.KeyLength != DefaultArgon2id.KeyLength {
+		t.Errorf(`ParseArgon2idParams = %+v`, h)
+	}
+
+	for _, s := range []string{"", "m=65536", "m=65536,t=0,p=1", "m=1,t=1,p=1"} {
+		if _, err := ParseArgon2idParams(s); err == nil {
+			t.Errorf(`ParseArgon2idParams(%q) returned no error`, s)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	cases := map[string]error{
+		"":                        ErrPasswordTooShort,
+		"abc123":                  ErrPasswordTooShort,
+		"abcdefgh":                ErrPasswordTooSimple,
+		"12345678":                ErrPasswordTooSimple,
+		"abcdefg1":                ErrPasswordTooSimple,
+		"P@ssw0rd":                ErrPasswordTooSimple,
+		"qwertyuiop":              ErrPasswordTooSimple,
+		"bob.smith1990":           ErrPasswordTooSimple,
+		"kx7#pq2m":                nil,
+		"correct horse battery":   nil,
+		"Grüße aus Köln":          nil,
+		strings.Repeat("aB", 129): ErrPasswordTooLong,
+	}
+	for password, want := range cases {
+		if got := ValidatePassword(password, "bob.smith@example.com"); !errors.Is(got, want) || (want == nil && got != nil) {
+			t.Errorf(`ValidatePassword(%q) = %v, want %v`, password, got, want)
+		}
+	}
+}
+
+func TestCheckPasswordLength(t *testing.T) {
+	password := strings.Repeat("aB", 40)
+	if err := CheckPasswordLength(DefaultArgon2id, password); err != nil {
+		t.Errorf(`CheckPasswordLength(argon2id, 80 bytes) = %v, want nil`, err)
+	}
+	err := CheckPasswordLength(DefaultBcrypt, password)
+	if !errors.Is(err, ErrPasswordTooLong) {
+		t.Fatalf(`CheckPasswordLength(bcrypt, 80 bytes) = %v, want ErrPasswordTooLong`, err)
+	}
+	if err.Error() != "password must be at most 72 bytes long" {
+		t.Errorf(`CheckPasswordLength(bcrypt, 80 bytes) error = %q`, err)
+	}
+	if err := CheckPasswordLength(DefaultBcrypt, password[:72]); err != nil {
+		t.Errorf(`CheckPasswordLength(bcrypt, 72 bytes) = %v, want nil`, err)
+	}
+}
@@ -0,0 +1,421 @@
+package auth
+
+import (
+	_ "embed"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	minPasswordLength = 8
+	// Long enough for passphrases while bounding the work of hashing.
+	maxPasswordBytes = 256
+	// maxScoredRunes bounds the work of EstimateStrength: patterns are only
+	// looked for this far into a password, and the rest counts as random.
+	maxScoredRunes = 100
+	// minPasswordScore is the weakest EstimateStrength score accepted for a
+	// new password.
+	minPasswordScore = 2
+)
+
+var (
+	ErrPasswordTooShort  = errors.New("password must be at least 8 characters long")
+	ErrPasswordTooLong   = errors.New("password is too long")
+	ErrPasswordTooSimple = errors.New("password is too easy to guess")
+)
+
+// WeakPasswordError is returned by ValidatePassword for passwords that score
+// too low. It matches ErrPasswordTooSimple with errors.Is.
+type WeakPasswordError struct {
+	Strength PasswordStrength
+}
+
+func (e *WeakPasswordError) Error() string {
+	msg := ErrPasswordTooSimple.Error()
+	if e.Strength.Warning != "" {
+		msg += ": " + e.Strength.Warning
+	}
+	if len(e.Strength.Suggestions) > 0 {
+		msg += ". " + strings.Join(e.Strength.Suggestions, " ")
+	}
+	return msg
+}
+
+func (e *WeakPasswordError) Unwrap() error {
+	return ErrPasswordTooSimple
+}
+
+// PasswordTooLongError is returned for passwords over Max bytes. It matches
+// ErrPasswordTooLong with errors.Is.
+type PasswordTooLongError struct {
+	Max int
+}
+
+func (e *PasswordTooLongError) Error() string {
+	return "password must be at most " + strconv.Itoa(e.Max) + " bytes long"
+}
+
+func (e *PasswordTooLongError) Unwrap() error {
+	return ErrPasswordTooLong
+}
+
+// MaxPasswordBytes is the longest password, in bytes, that h hashes in
+// full. Hashers with a lower limit of their own report it with a
+// MaxPasswordBytes method.
+func MaxPasswordBytes(h PasswordHasher) int {
+	if l, ok := h.(interface{ MaxPasswordBytes() int }); ok {
+		return min(l.MaxPasswordBytes(), maxPasswordBytes)
+	}
+	return maxPasswordBytes
+}
+
+// CheckPasswordLength refuses passwords that h cannot hash in full.
+func CheckPasswordLength(h PasswordHasher, password string) error {
+	if limit := MaxPasswordBytes(h); len(password) > limit {
+		return &PasswordTooLongError{Max: limit}
+	}
+	return nil
+}
+
+// ValidatePassword checks a new password against the strength rules.
+// userInputs are strings such as the email address that make a password
+// easier to guess when it contains them.
+func ValidatePassword(password string, userInputs ...string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > maxPasswordBytes {
+		return &PasswordTooLongError{Max: maxPasswordBytes}
+	}
+	if strength := EstimateStrength(password, userInputs...); strength.Score < minPasswordScore {
+		return &WeakPasswordError{Strength: strength}
+	}
+	return nil
+}
+
+// PasswordStrength estimates how hard a password is to guess, in the manner
+// of zxcvbn: the password is split into the cheapest sequence of guessable
+// patterns and the guesses needed for each are multiplied.
+type PasswordStrength struct {
+	// Score goes from 0, too guessable, to 4, very unguessable.
+	Score       int
+	Guesses     float64
+	Warning     string
+	Suggestions []string
+}
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords maps a lowercase password or word to its popularity rank.
+var commonPasswords = func() map[string]int {
+	ranks := map[string]int{}
+	for i, word := range strings.Fields(commonPasswordList) {
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = i + 1
+		}
+	}
+	return ranks
+}()
+
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm", "qazwsx", "1qaz2wsx"}
+
+var leetSubstitutions = map[rune]rune{'4': 'a', '@': 'a', '8': 'b', '3': 'e', '6': 'g', '1': 'i', '!': 'i', '0': 'o', '5': 's', '$': 's', '7': 't', '2': 'z'}
+
+type patternKind int
+
+const (
+	patternDictionary patternKind = iota
+	patternUserInput
+	patternSequence
+	patternRepeat
+	patternKeyboard
+	patternYear
+)
+
+type pattern struct {
+	kind    patternKind
+	i, j    int // rune offsets, j exclusive
+	guesses float64
+}
+
+// EstimateStrength scores password. Any userInputs found in it count as
+// nearly free guesses.
+func EstimateStrength(password string, userInputs ...string) PasswordStrength {
+	runes := []rune(password)
+	patterns := findPatterns(runes[:min(len(runes), maxScoredRunes)], userInputs)
+
+	// best[k] holds the fewest guesses for the first k runes, and how the
+	// last part was matched.
+	best := make([]float64, len(runes)+1)
+	last := make([]*pattern, len(runes)+1)
+	best[0] = 1
+	for k := 1; k <= len(runes); k++ {
+		// Anything else is brute-forced.
+		best[k] = best[k-1] * 10
+		last[k] = nil
+		for p := range patterns {
+			if patterns[p].j == k && best[patterns[p].i]*patterns[p].guesses < best[k] {
+				best[k] = best[patterns[p].i] * patterns[p].guesses
+				last[k] = &patterns[p]
+			}
+		}
+	}
+
+	var used []*pattern
+	for k := len(runes); k > 0; {
+		if last[k] == nil {
+			k--
+			continue
+		}
+		used = append(used, last[k])
+		k = last[k].i
+	}
+
+	guesses := best[len(runes)]
+	strength := PasswordStrength{Guesses: guesses, Score: scoreGuesses(guesses)}
+	if strength.Score < 3 {
+		strength.Warning, strength.Suggestions = passwordFeedback(runes, used)
+	}
+	return strength
+}
+
+func scoreGuesses(guesses float64) int {
+	switch {
+	case guesses < 1e3+5:
+		return 0
+	case guesses < 1e6+5:
+		return 1
+	case guesses < 1e8+5:
+		return 2
+	case guesses < 1e10+5:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func findPatterns(runes []rune, userInputs []string) []pattern {
+	lower := []rune(strings.ToLower(string(runes)))
+	unleet := make([]rune, len(lower))
+	for i, r := range lower {
+		if s, ok := leetSubstitutions[r]; ok {
+			unleet[i] = s
+		} else {
+			unleet[i] = r
+		}
+	}
+
+	inputs := map[string]int{}
+	for _, input := range userInputs {
+		for _, word := range strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if _, ok := inputs[word]; !ok {
+				inputs[word] = len(inputs) + 1
+			}
+		}
+	}
+
+	var patterns []pattern
+	for i := range runes {
+		for j := i + 3; j <= len(runes); j++ {
+			word, leet := string(lower[i:j]), string(unleet[i:j])
+			reversed := reverse(word)
+			for _, candidate := range []struct {
+				s    string
+				mult float64
+			}{{word, 1}, {leet, 2}, {reversed, 2}} {
+				if candidate.s == word && candidate.mult > 1 {
+					continue
+				}
+				variations := candidate.mult * caseVariations(runes[i:j])
+				if rank, ok := commonPasswords[candidate.s]; ok {
+					patterns = append(patterns, pattern{patternDictionary, i, j, float64(rank) * variations})
+				}
+				if rank, ok := inputs[candidate.s]; ok {
+					patterns = append(patterns, pattern{patternUserInput, i, j, float64(rank) * variations})
+				}
+			}
+		}
+	}
+
+	patterns = append(patterns, findSequences(lower)...)
+	patterns = append(patterns, findRepeats(lower)...)
+	patterns = append(patterns, findKeyboardRuns(lower)...)
+	patterns = append(patterns, findYears(lower)...)
+	return patterns
+}
+
+// findSequences matches runs like abc, 97531 or zyx.
+func findSequences(lower []rune) []pattern {
+	var patterns []pattern
+	for i := 0; i+2 < len(lower); {
+		delta := lower[i+1] - lower[i]
+		j := i + 1
+		for j+1 < len(lower) && lower[j+1]-lower[j] == delta {
+			j++
+		}
+		if j-i >= 2 && delta != 0 && delta >= -5 && delta <= 5 {
+			base := 26.0
+			switch {
+			case strings.ContainsRune("az019", lower[i]):
+				base = 4
+			case unicode.IsDigit(lower[i]):
+				base = 10
+			}
+			if delta < 0 {
+				base *= 2
+			}
+			patterns = append(patterns, pattern{patternSequence, i, j + 1, base * float64(j+1-i)})
+		}
+		if j > i+1 {
+			i = j
+		} else {
+			i++
+		}
+	}
+	return patterns
+}
+
+// findRepeats matches a unit of one or more runes repeated back to back,
+// like aaa or abcabc.
+func findRepeats(lower []rune) []pattern {
+	var patterns []pattern
+	for i := range lower {
+		for unit := 1; i+2*unit <= len(lower); unit++ {
+			n := 1
+			for i+(n+1)*unit <= len(lower) && string(lower[i+n*unit:i+(n+1)*unit]) == string(lower[i:i+unit]) {
+				n++
+			}
+			if n < 2 || (unit == 1 && n < 3) {
+				continue
+			}
+			unitGuesses := math.Pow(10, float64(unit))
+			patterns = append(patterns, pattern{patternRepeat, i, i + n*unit, unitGuesses * float64(n)})
+		}
+	}
+	return patterns
+}
+
+// findKeyboardRuns matches runs of neighbouring keys like qwerty or lkjh.
+func findKeyboardRuns(lower []rune) []pattern {
+	var patterns []pattern
+	s := string(lower)
+	for _, row := range keyboardRows {
+		for _, r := range []string{row, reverse(row)} {
+			for n := len(r); n >= 4; n-- {
+				for k := 0; k+n <= len(r); k++ {
+					run := r[k : k+n]
+					for from := 0; ; {
+						idx := strings.Index(s[from:], run)
+						if idx < 0 {
+							break
+						}
+						i := len([]rune(s[:from+idx]))
+						patterns = append(patterns, pattern{patternKeyboard, i, i + n, 12 * float64(n)})
+						from += idx + 1
+					}
+				}
+			}
+		}
+	}
+	return patterns
+}
+
+// findYears matches recent years, which are popular in passwords.
+func findYears(lower []rune) []pattern {
+	var patterns []pattern
+	now := time.Now().Year()
+	for i := 0; i+4 <= len(lower); i++ {
+		year, err := strconv.Atoi(string(lower[i : i+4]))
+		if err != nil || year < 1900 || year > 2099 {
+			continue
+		}
+		space := math.Max(math.Abs(float64(year-now)), 20)
+		patterns = append(patterns, pattern{patternYear, i, i + 4, space})
+	}
+	return patterns
+}
+
+// caseVariations counts the ways the letters of a word could have been
+// capitalised, with all lowercase, a capital first letter and all caps
+// being the common cases.
+func caseVariations(runes []rune) float64 {
+	upper, lower := 0, 0
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 || (upper == 1 && unicode.IsUpper(runes[0])) {
+		return 2
+	}
+	variations := 0.0
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += binomial(upper+lower, k)
+	}
+	return variations
+}
+
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return r
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// passwordFeedback explains what made a password weak, based on the
+// longest pattern it contains.
+func passwordFeedback(runes []rune, used []*pattern) (string, []string) {
+	suggestions := []string{"Add another word or two; uncommon words are better."}
+	var longest *pattern
+	for _, p := range used {
+		if longest == nil || p.j-p.i > longest.j-longest.i {
+			longest = p
+		}
+	}
+	if longest == nil {
+		if len(runes) < 12 {
+			suggestions = append(suggestions, "Use a longer password.")
+		}
+		return "this is a short password", suggestions
+	}
+
+	switch longest.kind {
+	case patternDictionary:
+		if longest.i == 0 && longest.j == len(runes) {
+			return "this is a very common password", suggestions
+		}
+		suggestions = append(suggestions, "Avoid common words and passwords, even with symbols or capitals added.")
+		return "this contains a common word or password", suggestions
+	case patternUserInput:
+		return "this contains your email address or handle", suggestions
+	case patternSequence:
+		return "sequences like abc or 6543 are easy to guess", append(suggestions, "Avoid sequences.")
+	case patternRepeat:
+		return "repeats like aaa or abcabc are easy to guess", append(suggestions, "Avoid repeated words and characters.")
+	case patternKeyboard:
+		return "rows of keys like qwerty are easy to guess", append(suggestions, "Use a longer keyboard pattern with more turns.")
+	default:
+		return "recent years are easy to guess", append(suggestions, "Avoid years that are associated with you.")
+	}
+}
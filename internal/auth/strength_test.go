@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEstimateStrength(t *testing.T) {
+	cases := []struct {
+		password string
+		score    int
+		warning  string
+	}{
+		{"password", 0, "this is a very common password"},
+		{"Password1", 0, "this contains a common word or password"},
+		{"abcdefgh", 0, "sequences like abc or 6543 are easy to guess"},
+		{"zzzzzzzzzz", 0, "repeats like aaa or abcabc are easy to guess"},
+		{"asdfghjk", 0, "rows of keys like qwerty are easy to guess"},
+		{"bob.smith", 0, "this contains your email address or handle"},
+		{"dJ8v!kq2LmZ9", 4, ""},
+	}
+	for _, c := range cases {
+		got := EstimateStrength(c.password, "bob.smith@example.com")
+		if got.Score != c.score || got.Warning != c.warning {
+			t.Errorf(`EstimateStrength(%q) = score %d, warning %q, want score %d, warning %q`, c.password, got.Score, got.Warning, c.score, c.warning)
+		}
+	}
+}
+
+func TestWeakPasswordError(t *testing.T) {
+	err := ValidatePassword("Password1")
+
+	var weak *WeakPasswordError
+	if !errors.As(err, &weak) {
+		t.Fatalf(`ValidatePassword("Password1") = %v, want a WeakPasswordError`, err)
+	}
+	if len(weak.Strength.Suggestions) == 0 {
+		t.Errorf(`WeakPasswordError has no suggestions`)
+	}
+	if !strings.HasPrefix(err.Error(), "password is too easy to guess: this contains a common word") {
+		t.Errorf(`ValidatePassword("Password1") error = %q`, err)
+	}
+}
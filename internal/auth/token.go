@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"net/http"
 
@@ -14,6 +15,13 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(key), nil
 }
 
+// HashToken returns the digest under which a random token is stored, so a
+// leaked table does not reveal usable tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GetBearerToken(headers http.Header) (string, error) {
 	// Get the Authorization header
 	authHeader := headers.Get("Authorization")
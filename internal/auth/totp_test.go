@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA-1 test vectors of RFC 6238, truncated to six digits.
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range cases {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf(`TOTPCode returned an error: %v`, err)
+		}
+		if got != want {
+			t.Errorf(`TOTPCode at %d = %s, want %s`, unix, got, want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf(`GenerateTOTPSecret returned an error: %v`, err)
+	}
+	now := time.Now()
+	step := TOTPStep(now)
+
+	previous, _ := TOTPCode(secret, step-1)
+	if got, ok := ValidateTOTP(secret, previous, now); !ok || got != step-1 {
+		t.Errorf(`ValidateTOTP with the previous code = %d, %v, want %d, true`, got, ok, step-1)
+	}
+	stale, _ := TOTPCode(secret, step-2)
+	if _, ok := ValidateTOTP(secret, stale, now); ok {
+		t.Errorf(`ValidateTOTP accepted a code two steps old`)
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Errorf(`ValidateTOTP accepted a short code`)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("JBSWY3DPEHPK3PXP", "Chirpy", "bob@example.com")
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf(`TOTPURI returned an invalid URI %q: %v`, uri, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Chirpy:bob@example.com" {
+		t.Errorf(`TOTPURI = %q`, uri)
+	}
+	if q := u.Query(); q.Get("secret") != "JBSWY3DPEHPK3PXP" || q.Get("issuer") != "Chirpy" {
+		t.Errorf(`TOTPURI query = %q`, u.RawQuery)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf(`GenerateRecoveryCodes returned an error: %v`, err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Errorf(`GenerateRecoveryCodes returned %q`, code)
+		}
+		seen[code] = true
+		if got := NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", " "))); got != code {
+			t.Errorf(`NormalizeRecoveryCode of %q = %q`, code, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Package blobstore stores opaque binary objects under string keys.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// BlobStore is the storage used for uploaded files. The methods mirror the
+// object operations of S3-compatible services so a remote implementation
+// can be swapped in for the local one.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any
+	// existing blob.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob stored under key. It returns ErrNotFound if
+	// there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob stored under key. Deleting a missing blob
+	// is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// validKey accepts slash-separated keys without empty, "." or ".."
+// segments, so keys can be used as relative paths.
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.Contains(segment, `\`) {
+			return false
+		}
+	}
+	return true
+}
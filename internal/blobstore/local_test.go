@@ -0,0 +1,75 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf(`NewLocalStore error: %v`, err)
+	}
+
+	err = store.Put(ctx, "a/b.png", strings.NewReader("data"), 4, "image/png")
+	if err != nil {
+		t.Fatalf(`Put error: %v`, err)
+	}
+
+	r, err := store.Get(ctx, "a/b.png")
+	if err != nil {
+		t.Fatalf(`Get error: %v`, err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "data" {
+		t.Errorf(`Get = %q, %v, want "data"`, data, err)
+	}
+
+	err = store.Delete(ctx, "a/b.png")
+	if err != nil {
+		t.Fatalf(`Delete error: %v`, err)
+	}
+	_, err = store.Get(ctx, "a/b.png")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf(`Get after Delete error = %v, want ErrNotFound`, err)
+	}
+	err = store.Delete(ctx, "a/b.png")
+	if err != nil {
+		t.Errorf(`second Delete error = %v, want nil`, err)
+	}
+}
+
+func TestLocalStoreShortRead(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf(`NewLocalStore error: %v`, err)
+	}
+
+	err = store.Put(context.Background(), "short", strings.NewReader("abc"), 4, "text/plain")
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf(`Put error = %v, want io.ErrUnexpectedEOF`, err)
+	}
+	_, err = store.Get(context.Background(), "short")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf(`Get error = %v, want ErrNotFound`, err)
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf(`NewLocalStore error: %v`, err)
+	}
+
+	for _, key := range []string{"", "/abs", "../up", "a/../b", "a//b", "a/", `a\b`} {
+		_, err := store.Get(context.Background(), key)
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf(`Get(%q) error = %v, want ErrInvalidKey`, key, err)
+		}
+	}
+}
@@ -7,29 +7,43 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, root_id, quote_of, preview_url, status, publish_at, published_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    CASE WHEN $10 = 'published' THEN $2::timestamp END
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, search_vector, like_count, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, rechirp_count, preview_url, status, publish_at, published_at
 `
 
 type CreateChirpParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Body      string
-	UserID    uuid.UUID
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Body       string
+	UserID     uuid.UUID
+	InReplyTo  uuid.NullUUID
+	RootID     uuid.NullUUID
+	QuoteOf    uuid.NullUUID
+	PreviewUrl sql.NullString
+	Status     string
+	PublishAt  sql.NullTime
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
@@ -39,6 +53,12 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		arg.UpdatedAt,
 		arg.Body,
 		arg.UserID,
+		arg.InReplyTo,
+		arg.RootID,
+		arg.QuoteOf,
+		arg.PreviewUrl,
+		arg.Status,
+		arg.PublishAt,
 	)
 	var i Chirp
 	err := row.Scan(
@@ -47,6 +67,18 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.LikeCount,
+		&i.InReplyTo,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.RechirpCount,
+		&i.PreviewUrl,
+		&i.Status,
+		&i.PublishAt,
+		&i.PublishedAt,
 	)
 	return i, err
 }
@@ -21,7 +21,7 @@ VALUES (
     $4,
     $5
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, deleted_at, handle, display_name, bio, avatar_media_id, role, suspended_until, banned_at, password_reset_required
 `
 
 type CreateUserParams struct {
@@ -48,6 +48,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PasswordResetRequired,
 	)
 	return i, err
 }
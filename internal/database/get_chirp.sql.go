@@ -12,7 +12,7 @@ import (
 )
 
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1 LIMIT 1
+SELECT id, created_at, updated_at, body, user_id, search_vector, like_count, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, rechirp_count, preview_url, status, publish_at, published_at FROM chirps WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -24,6 +24,18 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.SearchVector,
+		&i.LikeCount,
+		&i.InReplyTo,
+		&i.RootID,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.RechirpCount,
+		&i.PreviewUrl,
+		&i.Status,
+		&i.PublishAt,
+		&i.PublishedAt,
 	)
 	return i, err
 }
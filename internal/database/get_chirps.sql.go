@@ -10,9 +10,10 @@ import (
 )
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, search_vector, like_count, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, rechirp_count, preview_url, status, publish_at, published_at
 FROM chirps
-ORDER BY created_at ASC
+WHERE deleted_at IS NULL AND status = 'published'
+ORDER BY published_at ASC
 `
 
 func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
@@ -30,6 +31,18 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.LikeCount,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.RechirpCount,
+			&i.PreviewUrl,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
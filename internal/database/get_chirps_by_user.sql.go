@@ -12,10 +12,10 @@ import (
 )
 
 const getChirpsByUser = `-- name: GetChirpsByUser :many
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, search_vector, like_count, in_reply_to, root_id, deleted_at, rechirp_of, quote_of, rechirp_count, preview_url, status, publish_at, published_at
 FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC
+WHERE user_id = $1 AND deleted_at IS NULL AND status = 'published'
+ORDER BY published_at ASC
 `
 
 func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
@@ -33,6 +33,18 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.SearchVector,
+			&i.LikeCount,
+			&i.InReplyTo,
+			&i.RootID,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.RechirpCount,
+			&i.PreviewUrl,
+			&i.Status,
+			&i.PublishAt,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
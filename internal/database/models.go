@@ -6,17 +6,240 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	RevokedAt  sql.NullTime
+}
+
+type AuditLog struct {
+	ID           uuid.UUID
+	ActorID      uuid.NullUUID
+	Action       string
+	TargetUserID uuid.NullUUID
+	Reason       string
+	Details      json.RawMessage
+	CreatedAt    time.Time
+}
+
 type Chirp struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Body         string
+	UserID       uuid.NullUUID
+	SearchVector interface{}
+	LikeCount    int32
+	InReplyTo    uuid.NullUUID
+	RootID       uuid.NullUUID
+	DeletedAt    sql.NullTime
+	RechirpOf    uuid.NullUUID
+	QuoteOf      uuid.NullUUID
+	RechirpCount int32
+	PreviewUrl   sql.NullString
+	Status       string
+	PublishAt    sql.NullTime
+	PublishedAt  sql.NullTime
+}
+
+type ChirpAttachment struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+}
+
+type ChirpEvent struct {
+	ID        int64
+	CreatedAt time.Time
+	EventType string
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Body      string
+	RechirpOf uuid.NullUUID
+}
+
+type ChirpHashtag struct {
+	ChirpID    uuid.UUID
+	HashtagID  uuid.UUID
+	CreatedAt  time.Time
+	StartIndex int32
+	EndIndex   int32
+}
+
+type Draft struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Body      string
+	InReplyTo uuid.NullUUID
+	QuoteOf   uuid.NullUUID
+	MediaIds  []uuid.UUID
+	PublishAt sql.NullTime
+}
+
+type EmailChange struct {
+	UserID    uuid.UUID
+	NewEmail  string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type Export struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Status      string
+	StartedAt   sql.NullTime
+	CompletedAt sql.NullTime
+	StorageKey  sql.NullString
+	SizeBytes   sql.NullInt64
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FollowedID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Tag       string
+}
+
+type HashtagRollup struct {
+	HashtagID  uuid.UUID
+	Bucket     time.Time
+	ChirpCount int32
+}
+
+type HiddenHashtag struct {
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Like struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type LinkPreview struct {
+	Url         string
+	FetchedAt   time.Time
+	Ok          bool
+	Title       string
+	Description string
+	ImageUrl    string
+	SiteName    string
+}
+
+type MediaFile struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	ContentType string
+	SizeBytes   int64
+	Width       int32
+	Height      int32
+	StorageKey  string
+	Blurhash    string
+}
+
+type MediaVariant struct {
+	MediaID     uuid.UUID
+	Name        string
+	ContentType string
+	SizeBytes   int64
+	Width       int32
+	Height      int32
+	StorageKey  string
+}
+
+type Mention struct {
+	ChirpID    uuid.UUID
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	StartIndex int32
+	EndIndex   int32
+}
+
+type MfaChallenge struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Attempts  int32
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type OauthClient struct {
+	ID           string
+	SecretHash   sql.NullString
+	Name         string
+	RedirectUris []string
+	UserID       uuid.UUID
+	CreatedAt    time.Time
+}
+
+type OauthCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectUri   string
+	Scopes        []string
+	CodeChallenge string
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+}
+
+type OauthConsent struct {
+	UserID    uuid.UUID
+	ClientID  string
+	Scopes    []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type OauthGrant struct {
+	ID               uuid.UUID
+	RefreshTokenHash string
+	ClientID         string
+	UserID           uuid.UUID
+	Scopes           []string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	ExpiresAt        time.Time
+	RevokedAt        sql.NullTime
+}
+
+type PasswordReset struct {
+	UserID    uuid.UUID
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type RecoveryCode struct {
+	UserID    uuid.UUID
+	CodeHash  string
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
 }
 
 type RefreshToken struct {
@@ -28,11 +251,53 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
-type User struct {
-	ID             uuid.UUID
+type TotpCredential struct {
+	UserID         uuid.UUID
+	Secret         string
 	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	EnabledAt      sql.NullTime
+	LastStep       int64
+	FailedAttempts int32
+	LockedUntil    sql.NullTime
+}
+
+type TrendWatermark struct {
+	Name           string
+	ProcessedUntil time.Time
+}
+
+type User struct {
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Email                 string
+	HashedPassword        string
+	IsChirpyRed           bool
+	DeletedAt             sql.NullTime
+	Handle                sql.NullString
+	DisplayName           string
+	Bio                   string
+	AvatarMediaID         uuid.NullUUID
+	Role                  string
+	SuspendedUntil        sql.NullTime
+	BannedAt              sql.NullTime
+	PasswordResetRequired bool
+}
+
+type WebauthnChallenge struct {
+	Challenge []byte
+	Ceremony  string
+	UserID    uuid.NullUUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type WebauthnCredential struct {
+	ID         []byte
+	UserID     uuid.UUID
+	PublicKey  []byte
+	SignCount  int64
+	Name       string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
 }
@@ -7,13 +7,21 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
 const resetUsers = `-- name: ResetUsers :exec
-DELETE FROM users
+WITH deleted_users AS (
+    UPDATE users
+    SET deleted_at = $1, updated_at = $1
+    WHERE deleted_at IS NULL
+)
+UPDATE chirps
+SET deleted_at = $1, updated_at = $1
+WHERE deleted_at IS NULL
 `
 
-func (q *Queries) ResetUsers(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, resetUsers)
+func (q *Queries) ResetUsers(ctx context.Context, deletedAt sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, resetUsers, deletedAt)
 	return err
 }
@@ -1,46 +0,0 @@
-// Code generated by sqlc. DO NOT EDIT.
-// versions:
-//   sqlc v1.29.0
-// source: update_user.sql
-
-package database
-
-import (
-	"context"
-	"time"
-
-	"github.com/google/uuid"
-)
-
-const updateUser = `-- name: UpdateUser :one
-UPDATE users
-SET email = $2, hashed_password = $3, updated_at = $4
-WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
-`
-
-type UpdateUserParams struct {
-	ID             uuid.UUID
-	Email          string
-	HashedPassword string
-	UpdatedAt      time.Time
-}
-
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser,
-		arg.ID,
-		arg.Email,
-		arg.HashedPassword,
-		arg.UpdatedAt,
-	)
-	var i User
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Email,
-		&i.HashedPassword,
-		&i.IsChirpyRed,
-	)
-	return i, err
-}
@@ -15,8 +15,8 @@ import (
 const updateUserRed = `-- name: UpdateUserRed :one
 UPDATE users
 SET is_chirpy_red = $2, updated_at = $3
-WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, deleted_at, handle, display_name, bio, avatar_media_id, role, suspended_until, banned_at, password_reset_required
 `
 
 type UpdateUserRedParams struct {
@@ -35,6 +35,15 @@ func (q *Queries) UpdateUserRed(ctx context.Context, arg UpdateUserRedParams) (U
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.DeletedAt,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarMediaID,
+		&i.Role,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.PasswordResetRequired,
 	)
 	return i, err
 }
@@ -0,0 +1,131 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+const maxHashtagLength = 100
+
+// Positions are rune offsets into the chirp body, with End exclusive.
+
+type Hashtag struct {
+	Tag   string
+	Start int
+	End   int
+}
+
+// Mentions name a user by handle. Email addresses are never mentions, so a
+// chirp cannot tell whether an address has an account.
+type Mention struct {
+	Text  string
+	Start int
+	End   int
+}
+
+type Entities struct {
+	Hashtags []Hashtag
+	Mentions []Mention
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isEmailRune(r rune) bool {
+	return isWordRune(r) || strings.ContainsRune(".%+-", r)
+}
+
+// scan returns the end of the run of runes starting at start accepted by ok.
+func scan(runes []rune, start int, ok func(rune) bool) int {
+	end := start
+	for end < len(runes) && ok(runes[end]) {
+		end++
+	}
+	return end
+}
+
+func parseHashtag(runes []rune, start int) (Hashtag, bool) {
+	end := scan(runes, start+1, isWordRune)
+	word := runes[start+1 : end]
+	if len(word) == 0 || len(word) > maxHashtagLength {
+		return Hashtag{}, false
+	}
+	hasLetter := false
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+			break
+		}
+	}
+	if !hasLetter {
+		return Hashtag{}, false
+	}
+	return Hashtag{Tag: strings.ToLower(string(word)), Start: start, End: end}, true
+}
+
+// isEmail reports whether the text after the @ at start is the local part
+// of an email address.
+func isEmail(runes []rune, start int) bool {
+	at := scan(runes, start+1, isEmailRune)
+	return at < len(runes) && runes[at] == '@'
+}
+
+// parseMention reads an @-prefixed handle.
+func parseMention(runes []rune, start int) (Mention, bool) {
+	if isEmail(runes, start) {
+		return Mention{}, false
+	}
+	end := scan(runes, start+1, isHandleRune)
+	if end < len(runes) && isWordRune(runes[end]) {
+		return Mention{}, false
+	}
+	handle := runes[start+1 : end]
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return Mention{}, false
+	}
+	return Mention{Text: strings.ToLower(string(handle)), Start: start, End: end}, true
+}
+
+// Parse extracts hashtags and mentions from a chirp body. Entities must
+// start at the beginning of the body or after a non-word character.
+func Parse(body string) Entities {
+	result := Entities{
+		Hashtags: make([]Hashtag, 0),
+		Mentions: make([]Mention, 0),
+	}
+
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+		switch runes[i] {
+		case '#':
+			if hashtag, ok := parseHashtag(runes, i); ok {
+				result.Hashtags = append(result.Hashtags, hashtag)
+				i = hashtag.End - 1
+			}
+		case '@':
+			if mention, ok := parseMention(runes, i); ok {
+				result.Mentions = append(result.Mentions, mention)
+				i = mention.End - 1
+			}
+		}
+	}
+
+	return result
+}
+
+// Tags returns the distinct hashtags in order of first appearance.
+func (e Entities) Tags() []string {
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+	for _, hashtag := range e.Hashtags {
+		if !seen[hashtag.Tag] {
+			seen[hashtag.Tag] = true
+			tags = append(tags, hashtag.Tag)
+		}
+	}
+	return tags
+}
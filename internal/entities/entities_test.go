@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	body := "Hello @Bob@Example.com, loving #Go and #go! email me at a@b.com #123 #café."
+
+	got := Parse(body)
+
+	wantHashtags := []Hashtag{
+		{Tag: "go", Start: 31, End: 34},
+		{Tag: "go", Start: 39, End: 42},
+		{Tag: "café", Start: 69, End: 74},
+	}
+	if !reflect.DeepEqual(got.Hashtags, wantHashtags) {
+		t.Errorf(`Parse(%q).Hashtags = %v, want %v`, body, got.Hashtags, wantHashtags)
+	}
+
+	if len(got.Mentions) != 0 {
+		t.Errorf(`Parse(%q).Mentions = %v, want none`, body, got.Mentions)
+	}
+
+	if tags := got.Tags(); !reflect.DeepEqual(tags, []string{"go", "café"}) {
+		t.Errorf(`Tags() = %v, want [go café]`, tags)
+	}
+}
+
+func TestParseHandleMentions(t *testing.T) {
+	body := "@Alice_1 and (@bob), not @jo, @waytoolonghandle_x, @émile, x@carol or @dave.smith@example.com"
+
+	got := Parse(body).Mentions
+
+	want := []Mention{
+		{Text: "alice_1", Start: 0, End: 8},
+		{Text: "bob", Start: 14, End: 18},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(`Parse(%q).Mentions = %v, want %v`, body, got, want)
+	}
+}
+
+func TestValidateHandle(t *testing.T) {
+	cases := map[string]error{
+		"alice":            nil,
+		"Bob_42":           nil,
+		"abc":              nil,
+		"ab":               ErrInvalidHandle,
+		"fifteen_chars_x":  nil,
+		"sixteen_chars_xx": ErrInvalidHandle,
+		"with-dash":        ErrInvalidHandle,
+		"émile":            ErrInvalidHandle,
+		"Admin":            ErrReservedHandle,
+		"support":          ErrReservedHandle,
+	}
+	for handle, want := range cases {
+		if got := ValidateHandle(handle); got != want {
+			t.Errorf(`ValidateHandle(%q) = %v, want %v`, handle, got, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package entities
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	minHandleLength = 3
+	maxHandleLength = 15
+)
+
+var (
+	ErrInvalidHandle  = errors.New("handle must be 3 to 15 letters, digits or underscores")
+	ErrReservedHandle = errors.New("handle is reserved")
+)
+
+// Reserved handles could be mistaken for the service itself or for routes.
+var reservedHandles = map[string]bool{
+	"2fa":           true,
+	"about":         true,
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"chirpy":        true,
+	"export":        true,
+	"help":          true,
+	"login":         true,
+	"logout":        true,
+	"me":            true,
+	"moderator":     true,
+	"null":          true,
+	"official":      true,
+	"root":          true,
+	"security":      true,
+	"settings":      true,
+	"signup":        true,
+	"staff":         true,
+	"support":       true,
+	"system":        true,
+}
+
+func isHandleRune(r rune) bool {
+	return r < 128 && isWordRune(r)
+}
+
+// ValidateHandle checks a handle as chosen by a user, without the @.
+// Handles are unique regardless of case.
+func ValidateHandle(handle string) error {
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return ErrInvalidHandle
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return ErrInvalidHandle
+		}
+	}
+	if reservedHandles[strings.ToLower(handle)] {
+		return ErrReservedHandle
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	msg, err := buildMessage("Chirpy <noreply@chirpy.test>", "bob@example.com", "Confirm your émail", "Hello\nBob", date)
+	if err != nil {
+		t.Fatalf(`buildMessage error: %v`, err)
+	}
+
+	got := string(msg)
+	for _, want := range []string{
+		"From: Chirpy <noreply@chirpy.test>\r\n",
+		"To: bob@example.com\r\n",
+		"Subject: =?utf-8?q?Confirm_your_=C3=A9mail?=\r\n",
+		"Date: Wed, 01 May 2024 12:00:00 +0000\r\n",
+		"\r\n\r\nHello\r\nBob",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf(`buildMessage = %q, want it to contain %q`, got, want)
+		}
+	}
+}
+
+func TestBuildMessageRejectsHeaderInjection(t *testing.T) {
+	_, err := buildMessage("noreply@chirpy.test", "bob@example.com\r\nBcc: eve@example.com", "Hi", "", time.Now())
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf(`buildMessage with a line break in To error = %v, want ErrInvalidHeader`, err)
+	}
+}
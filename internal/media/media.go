@@ -0,0 +1,158 @@
+// Package media turns uploaded images into safe, metadata-free files with
+// thumbnails and a blurhash placeholder.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	ErrTooLarge          = errors.New("media: file is too large")
+	ErrUnsupportedFormat = errors.New("media: unsupported format")
+	ErrDimensions        = errors.New("media: image dimensions are too large")
+	ErrInvalidImage      = errors.New("media: invalid image")
+)
+
+const jpegQuality = 85
+
+// Thumbnail is a scaled-down copy whose longest edge is Size pixels.
+type Thumbnail struct {
+	Name string
+	Size int
+}
+
+type Options struct {
+	MaxSize      int
+	MaxDimension int
+	Thumbnails   []Thumbnail
+}
+
+var DefaultOptions = Options{
+	MaxSize:      5 << 20,
+	MaxDimension: 4096,
+	Thumbnails: []Thumbnail{
+		{Name: "small", Size: 150},
+		{Name: "medium", Size: 480},
+		{Name: "large", Size: 1024},
+	},
+}
+
+// Variant is one encoded version of an image.
+type Variant struct {
+	Name        string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+type Result struct {
+	Original   Variant
+	Thumbnails []Variant
+	BlurHash   string
+}
+
+// decoders are the accepted sniffed content types.
+var decoders = map[string]bool{
+	"image/gif":  true,
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+// Process validates and re-encodes an image. The output never carries the
+// metadata of the input: JPEG orientation is applied to the pixels and
+// everything else is dropped. Animated GIFs keep their first frame only.
+func Process(data []byte, opts Options) (*Result, error) {
+	if len(data) > opts.MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !decoders[contentType] {
+		return nil, ErrUnsupportedFormat
+	}
+
+	// Check the dimensions before allocating the pixels.
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > opts.MaxDimension || config.Height > opts.MaxDimension {
+		return nil, ErrDimensions
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	img := image.NewRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	if contentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+
+	res := &Result{}
+	res.Original, err = encode("original", img, contentType == "image/jpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	for _, thumb := range opts.Thumbnails {
+		if w <= thumb.Size && h <= thumb.Size {
+			continue
+		}
+		tw, th := fit(w, h, thumb.Size)
+		v, err := encode(thumb.Name, resize(img, tw, th), img.Opaque())
+		if err != nil {
+			return nil, err
+		}
+		res.Thumbnails = append(res.Thumbnails, v)
+	}
+
+	// The placeholder only needs a handful of pixels.
+	bw, bh := fit(w, h, 32)
+	res.BlurHash = BlurHash(resize(img, bw, bh), 4, 3)
+
+	return res, nil
+}
+
+// encode writes JPEG for photos and PNG for everything else, so
+// transparency survives.
+func encode(name string, img *image.RGBA, asJPEG bool) (Variant, error) {
+	var buf bytes.Buffer
+	contentType := "image/png"
+	var err error
+	if asJPEG {
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return Variant{}, err
+	}
+	return Variant{
+		Name:        name,
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// fit scales w×h so that the longest edge is size, keeping at least one
+// pixel on each side.
+func fit(w, h, size int) (int, int) {
+	if w >= h {
+		return size, max(1, h*size/w)
+	}
+	return max(1, w*size/h), size
+}
@@ -0,0 +1,168 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// withExif inserts an APP1 segment with the given orientation after SOI.
+func withExif(jpegData []byte, orientation uint16) []byte {
+	payload := []byte("Exif\x00\x00II\x2A\x00")
+	payload = binary.LittleEndian.AppendUint32(payload, 8)
+	payload = binary.LittleEndian.AppendUint16(payload, 1)
+	payload = binary.LittleEndian.AppendUint16(payload, 0x0112)
+	payload = binary.LittleEndian.AppendUint16(payload, 3)
+	payload = binary.LittleEndian.AppendUint32(payload, 1)
+	payload = binary.LittleEndian.AppendUint16(payload, orientation)
+	payload = append(payload, 0, 0, 0, 0, 0, 0)
+
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestProcessJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(300, 200), nil); err != nil {
+		t.Fatalf(`jpeg.Encode error: %v`, err)
+	}
+	data := withExif(buf.Bytes(), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf(`jpegOrientation = %d, want 6`, jpegOrientation(data))
+	}
+
+	res, err := Process(data, DefaultOptions)
+	if err != nil {
+		t.Fatalf(`Process error: %v`, err)
+	}
+	if bytes.Contains(res.Original.Data, []byte("Exif")) {
+		t.Errorf(`original still contains EXIF data`)
+	}
+	if res.Original.ContentType != "image/jpeg" {
+		t.Errorf(`original content type = %s, want image/jpeg`, res.Original.ContentType)
+	}
+	// Orientation 6 rotates the image by 90 degrees.
+	if res.Original.Width != 200 || res.Original.Height != 300 {
+		t.Errorf(`original is %dx%d, want 200x300`, res.Original.Width, res.Original.Height)
+	}
+	if len(res.Thumbnails) != 1 {
+		t.Fatalf(`got %d thumbnails, want 1`, len(res.Thumbnails))
+	}
+	thumb := res.Thumbnails[0]
+	if thumb.Name != "small" || thumb.Width != 100 || thumb.Height != 150 {
+		t.Errorf(`thumbnail is %s %dx%d, want small 100x150`, thumb.Name, thumb.Width, thumb.Height)
+	}
+	if len(res.BlurHash) != 28 {
+		t.Errorf(`blurhash %q has length %d, want 28`, res.BlurHash, len(res.BlurHash))
+	}
+}
+
+func TestProcessPNGKeepsFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(20, 10)); err != nil {
+		t.Fatalf(`png.Encode error: %v`, err)
+	}
+
+	res, err := Process(buf.Bytes(), DefaultOptions)
+	if err != nil {
+		t.Fatalf(`Process error: %v`, err)
+	}
+	if res.Original.ContentType != "image/png" {
+		t.Errorf(`original content type = %s, want image/png`, res.Original.ContentType)
+	}
+	if len(res.Thumbnails) != 0 {
+		t.Errorf(`got %d thumbnails for a small image, want 0`, len(res.Thumbnails))
+	}
+}
+
+func TestProcessRejects(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(20, 10)); err != nil {
+		t.Fatalf(`png.Encode error: %v`, err)
+	}
+
+	opts := DefaultOptions
+	opts.MaxDimension = 16
+	if _, err := Process(buf.Bytes(), opts); !errors.Is(err, ErrDimensions) {
+		t.Errorf(`Process error = %v, want ErrDimensions`, err)
+	}
+
+	opts = DefaultOptions
+	opts.MaxSize = 10
+	if _, err := Process(buf.Bytes(), opts); !errors.Is(err, ErrTooLarge) {
+		t.Errorf(`Process error = %v, want ErrTooLarge`, err)
+	}
+
+	if _, err := Process([]byte("hello"), DefaultOptions); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf(`Process error = %v, want ErrUnsupportedFormat`, err)
+	}
+
+	truncated := buf.Bytes()[:len(buf.Bytes())/2]
+	if _, err := Process(truncated, DefaultOptions); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf(`Process error = %v, want ErrInvalidImage`, err)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	a := color.RGBA{R: 255, A: 255}
+	b := color.RGBA{B: 255, A: 255}
+	src.Set(0, 0, a)
+	src.Set(1, 0, b)
+
+	dst := orient(src, 6)
+	if dst.Bounds().Dx() != 1 || dst.Bounds().Dy() != 2 {
+		t.Fatalf(`rotated size = %v, want 1x2`, dst.Bounds())
+	}
+	if dst.RGBAAt(0, 0) != a || dst.RGBAAt(0, 1) != b {
+		t.Errorf(`rotated pixels = %v, %v, want %v, %v`, dst.RGBAAt(0, 0), dst.RGBAAt(0, 1), a, b)
+	}
+}
+
+func TestBlurHashSolid(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	// 4x3 components, then the average color (white) in the DC term.
+	got := BlurHash(img, 4, 3)
+	if len(got) != 28 || got[0] != 'L' || got[2:6] != "TSUA" {
+		t.Errorf(`BlurHash = %q, want size flag L and DC TSUA`, got)
+	}
+}
+
+func TestPoolCancelled(t *testing.T) {
+	p := NewPool(1, DefaultOptions)
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Process(ctx, []byte("hello")); !errors.Is(err, context.Canceled) {
+		t.Errorf(`Process error = %v, want context.Canceled`, err)
+	}
+	if _, err := p.Process(context.Background(), []byte("hello")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf(`Process error = %v, want ErrUnsupportedFormat`, err)
+	}
+}
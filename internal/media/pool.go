@@ -0,0 +1,71 @@
+package media
+
+import "context"
+
+// Pool processes images on a fixed number of goroutines, so concurrent
+// uploads cannot decode more images at once than there are workers.
+type Pool struct {
+	opts Options
+	jobs chan job
+}
+
+type job struct {
+	ctx  context.Context
+	data []byte
+	done chan<- jobResult
+}
+
+type jobResult struct {
+	res *Result
+	err error
+}
+
+func NewPool(workers int, opts Options) *Pool {
+	p := &Pool{
+		opts: opts,
+		jobs: make(chan job),
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) work() {
+	for j := range p.jobs {
+		// Skip work nobody is waiting for anymore.
+		if j.ctx.Err() != nil {
+			j.done <- jobResult{err: j.ctx.Err()}
+			continue
+		}
+		res, err := Process(j.data, p.opts)
+		j.done <- jobResult{res: res, err: err}
+	}
+}
+
+// Process runs Process on the next free worker. It returns early with the
+// context error if ctx is done first.
+func (p *Pool) Process(ctx context.Context, data []byte) (*Result, error) {
+	done := make(chan jobResult, 1)
+	select {
+	case p.jobs <- job{ctx: ctx, data: data, done: done}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Options returns the options the pool processes images with.
+func (p *Pool) Options() Options {
+	return p.opts
+}
+
+// Close stops the workers once the queued images are processed.
+func (p *Pool) Close() {
+	close(p.jobs)
+}
@@ -0,0 +1,93 @@
+package pubsub
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ChirpCreated  = "chirp.created"
+	ChirpDeleted  = "chirp.deleted"
+	ChirpRestored = "chirp.restored"
+)
+
+type Event struct {
+	ID        int64
+	Type      string
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Body      string
+	RechirpOf uuid.NullUUID
+	// Mentions lists the users mentioned in the chirp body.
+	Mentions []uuid.UUID
+}
+
+type Subscription struct {
+	// C is closed when the subscription ends, either by Unsubscribe or
+	// because the subscriber fell behind and its buffer filled up.
+	C      <-chan Event
+	ch     chan Event
+	filter func(Event) bool
+	lagged bool
+}
+
+// Lagged reports whether the subscription was dropped for being too slow.
+// It is only meaningful after C has been closed.
+func (s *Subscription) Lagged() bool {
+	return s.lagged
+}
+
+type Broker struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe registers a subscriber that receives every published event
+// accepted by filter. A nil filter accepts all events.
+func (b *Broker) Subscribe(size int, filter func(Event) bool) *Subscription {
+	ch := make(chan Event, size)
+	sub := &Subscription{C: ch, ch: ch, filter: filter}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Publish delivers the event to all matching subscribers without blocking.
+// Subscribers whose buffer is full are dropped and marked as lagged.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			sub.lagged = true
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBrokerFilter(t *testing.T) {
+	b := NewBroker()
+	author := uuid.New()
+
+	all := b.Subscribe(4, nil)
+	byAuthor := b.Subscribe(4, func(e Event) bool { return e.UserID == author })
+
+	b.Publish(Event{ID: 1, Type: ChirpCreated, UserID: uuid.New()})
+	b.Publish(Event{ID: 2, Type: ChirpCreated, UserID: author})
+
+	if got := len(all.C); got != 2 {
+		t.Errorf(`unfiltered subscriber received %d events, want 2`, got)
+	}
+	if got := len(byAuthor.C); got != 1 {
+		t.Fatalf(`filtered subscriber received %d events, want 1`, got)
+	}
+	if e := <-byAuthor.C; e.ID != 2 {
+		t.Errorf(`filtered subscriber received event %d, want 2`, e.ID)
+	}
+}
+
+func TestBrokerLagged(t *testing.T) {
+	b := NewBroker()
+	sub := b.Subscribe(1, nil)
+
+	b.Publish(Event{ID: 1})
+	b.Publish(Event{ID: 2})
+
+	<-sub.C
+	if _, ok := <-sub.C; ok {
+		t.Fatalf(`expected subscription to be closed after overflow`)
+	}
+	if !sub.Lagged() {
+		t.Errorf(`Lagged() = false, want true`)
+	}
+
+	// Unsubscribing a dropped subscription must not panic.
+	b.Unsubscribe(sub)
+}
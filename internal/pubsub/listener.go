@@ -0,0 +1,82 @@
+package pubsub
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres notification channel fed by the chirp_events trigger.
+const Channel = "chirp_events"
+
+// Listen relays chirp events announced through Postgres LISTEN/NOTIFY to the
+// local subscribers, so every instance sharing the database sees every event.
+// It blocks until ctx is cancelled.
+func (b *Broker) Listen(ctx context.Context, dbURL string, db *database.Queries) {
+	listener := pq.NewListener(dbURL, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Error on event listener: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(Channel)
+	if err != nil {
+		log.Printf("Error listening on channel %s: %s", Channel, err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established;
+			// clients catch up on missed events with Last-Event-ID.
+			if n == nil {
+				continue
+			}
+			id, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				log.Printf("Invalid event ID %q: %s", n.Extra, err)
+				continue
+			}
+			dbEvent, err := db.GetChirpEvent(ctx, id)
+			if err != nil {
+				log.Printf("Error getting chirp event %d: %s", id, err)
+				continue
+			}
+			e := FromDatabase(dbEvent)
+			if e.Type == ChirpCreated {
+				dbMentions, err := db.GetMentionsByChirps(ctx, []uuid.UUID{e.ChirpID})
+				if err != nil {
+					log.Printf("Error getting chirp mentions: %s", err)
+				}
+				for _, dbMention := range dbMentions {
+					e.Mentions = append(e.Mentions, dbMention.UserID)
+				}
+			}
+			b.Publish(e)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+func FromDatabase(dbEvent database.ChirpEvent) Event {
+	return Event{
+		ID:        dbEvent.ID,
+		Type:      dbEvent.EventType,
+		CreatedAt: dbEvent.CreatedAt,
+		ChirpID:   dbEvent.ChirpID,
+		UserID:    dbEvent.UserID,
+		Body:      dbEvent.Body,
+		RechirpOf: dbEvent.RechirpOf,
+	}
+}
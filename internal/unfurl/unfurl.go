@@ -0,0 +1,259 @@
+// Package unfurl fetches OpenGraph and Twitter card metadata for links.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrBlockedAddress = errors.New("unfurl: address is not public")
+	ErrNotHTML        = errors.New("unfurl: response is not HTML")
+	ErrNoPreview      = errors.New("unfurl: page has no preview metadata")
+)
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+)
+
+type Preview struct {
+	URL         string
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Fetcher looks up the preview of a page.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (*Preview, error)
+}
+
+// HTTPFetcher fetches pages over HTTP. Connections are only made to public
+// addresses, checked after name resolution so DNS cannot point the fetcher
+// at internal services.
+type HTTPFetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+type Options struct {
+	Timeout      time.Duration
+	MaxBytes     int64
+	MaxRedirects int
+	// AllowAddr reports whether connecting to an address is allowed. It
+	// defaults to PublicAddr.
+	AllowAddr func(netip.Addr) bool
+}
+
+var DefaultOptions = Options{
+	Timeout:      5 * time.Second,
+	MaxBytes:     512 << 10,
+	MaxRedirects: 3,
+}
+
+func NewHTTPFetcher(opts Options) *HTTPFetcher {
+	allow := opts.AllowAddr
+	if allow == nil {
+		allow = PublicAddr
+	}
+	dialer := &net.Dialer{
+		Timeout: opts.Timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if !allow(addr) {
+				return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+			}
+			return nil
+		},
+	}
+	return &HTTPFetcher{
+		client: &http.Client{
+			Timeout: opts.Timeout,
+			Transport: &http.Transport{
+				// No proxy: it would make the connection on our behalf.
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   opts.Timeout,
+				ResponseHeaderTimeout: opts.Timeout,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       30 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > opts.MaxRedirects {
+					return errors.New("unfurl: too many redirects")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return fmt.Errorf("unfurl: unsupported redirect scheme %q", req.URL.Scheme)
+				}
+				return nil
+			},
+		},
+		maxBytes: opts.MaxBytes,
+	}
+}
+
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// PublicAddr reports whether addr is a globally routable unicast address.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unfurl: unsupported scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "Chirpy-Unfurler/1.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unfurl: unexpected status %s", resp.Status)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return nil, ErrNotHTML
+	}
+
+	// Metadata lives in the head, so a truncated page is fine.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	p := parse(string(body), resp.Request.URL)
+	if p.Title == "" {
+		return nil, ErrNoPreview
+	}
+	p.URL = rawURL
+	return p, nil
+}
+
+var (
+	metaTag   = regexp.MustCompile(`(?is)<meta\s([^>]*)>`)
+	attribute = regexp.MustCompile(`(?is)([a-z_:.-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	titleTag  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	headEnd   = regexp.MustCompile(`(?i)</head>`)
+)
+
+// parse reads the preview metadata from the head of a page. OpenGraph
+// properties win over Twitter card ones, which win over plain HTML.
+func parse(page string, base *url.URL) *Preview {
+	if loc := headEnd.FindStringIndex(page); loc != nil {
+		page = page[:loc[0]]
+	}
+
+	meta := make(map[string]string)
+	for _, tag := range metaTag.FindAllStringSubmatch(page, -1) {
+		attrs := make(map[string]string)
+		for _, a := range attribute.FindAllStringSubmatch(tag[1], -1) {
+			attrs[strings.ToLower(a[1])] = a[2] + a[3] + a[4]
+		}
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		if _, ok := meta[key]; !ok && key != "" {
+			meta[key] = strings.TrimSpace(html.UnescapeString(attrs["content"]))
+		}
+	}
+	if m := titleTag.FindStringSubmatch(page); m != nil {
+		meta["title"] = strings.TrimSpace(html.UnescapeString(m[1]))
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if meta[key] != "" {
+				return meta[key]
+			}
+		}
+		return ""
+	}
+
+	p := &Preview{
+		Title:       truncate(first("og:title", "twitter:title", "title"), maxTitleLength),
+		Description: truncate(first("og:description", "twitter:description", "description"), maxDescriptionLength),
+		SiteName:    truncate(first("og:site_name"), maxTitleLength),
+	}
+	if image := first("og:image", "og:image:url", "twitter:image", "twitter:image:src"); image != "" {
+		if u, err := base.Parse(image); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			p.ImageURL = u.String()
+		}
+	}
+	return p
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// ExtractURLs returns the http and https links in a chirp body, without
+// trailing punctuation.
+func ExtractURLs(body string) []string {
+	urls := make([]string, 0)
+	for _, match := range linkPattern.FindAllString(body, -1) {
+		match = strings.TrimRight(match, ".,;:!?)]}'")
+		if u, err := url.Parse(match); err == nil && u.Host != "" {
+			urls = append(urls, match)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,120 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func allowAll(netip.Addr) bool { return true }
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head>
+<title>Plain title</title>
+<meta name="description" content="Plain description">
+<meta property="og:title" content="Tom &amp; Jerry">
+<meta content='/img/cover.png' property='og:image'>
+<meta name="twitter:description" content="Card description">
+</head><body><meta property="og:site_name" content="Ignored"></body></html>`))
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions
+	opts.AllowAddr = allowAll
+	p, err := NewHTTPFetcher(opts).Fetch(context.Background(), srv.URL+"/page")
+	if err != nil {
+		t.Fatalf(`Fetch error: %v`, err)
+	}
+	if p.Title != "Tom & Jerry" {
+		t.Errorf(`Title = %q, want "Tom & Jerry"`, p.Title)
+	}
+	if p.Description != "Card description" {
+		t.Errorf(`Description = %q, want "Card description"`, p.Description)
+	}
+	if p.ImageURL != srv.URL+"/img/cover.png" {
+		t.Errorf(`ImageURL = %q, want %q`, p.ImageURL, srv.URL+"/img/cover.png")
+	}
+	if p.SiteName != "" {
+		t.Errorf(`SiteName = %q, want "" (outside the head)`, p.SiteName)
+	}
+}
+
+func TestFetchRejects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+		case "/empty":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head></head></html>`))
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	_, err := NewHTTPFetcher(DefaultOptions).Fetch(context.Background(), srv.URL+"/empty")
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf(`Fetch from loopback error = %v, want ErrBlockedAddress`, err)
+	}
+
+	opts := DefaultOptions
+	opts.AllowAddr = allowAll
+	f := NewHTTPFetcher(opts)
+	if _, err := f.Fetch(context.Background(), srv.URL+"/json"); !errors.Is(err, ErrNotHTML) {
+		t.Errorf(`Fetch JSON error = %v, want ErrNotHTML`, err)
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/empty"); !errors.Is(err, ErrNoPreview) {
+		t.Errorf(`Fetch empty page error = %v, want ErrNoPreview`, err)
+	}
+	if _, err := f.Fetch(context.Background(), srv.URL+"/loop"); err == nil || !strings.Contains(err.Error(), "redirects") {
+		t.Errorf(`Fetch redirect loop error = %v, want too many redirects`, err)
+	}
+	if _, err := f.Fetch(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Errorf(`Fetch file URL succeeded, want error`)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	cases := map[string]bool{
+		"8.8.8.8":              true,
+		"2606:4700::1111":      true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::1":                  false,
+		"fd00::1":              false,
+		"fe80::1":              false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:93.184.216.34": true,
+	}
+	for addr, want := range cases {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf(`PublicAddr(%s) = %v, want %v`, addr, got, want)
+		}
+	}
+}
+
+func TestExtractURLs(t *testing.T) {
+	got := ExtractURLs("see https://example.com/a?b=1, and (http://x.org/path). not ftp://nope.com or https://")
+	want := []string{"https://example.com/a?b=1", "http://x.org/path"}
+	if len(got) != len(want) {
+		t.Fatalf(`ExtractURLs = %q, want %q`, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf(`ExtractURLs[%d] = %q, want %q`, i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package webauthn
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Examples from RFC 8949, appendix A.
+func TestDecodeCBOR(t *testing.T) {
+	cases := []struct {
+		hex  string
+		want any
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1b000000e8d4a51000", int64(1000000000000)},
+		{"20", int64(-1)},
+		{"3903e7", int64(-1000)},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f93c00", 1.0},
+		{"f9c400", -4.0},
+		{"fa47c35000", 100000.0},
+		{"fb3ff199999999999a", 1.1},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"6449455446", "IETF"},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{"a26161016162820203", map[any]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+	}
+	for _, c := range cases {
+		data, _ := hex.DecodeString(c.hex)
+		got, rest, err := decodeCBOR(data)
+		if err != nil {
+			t.Errorf(`decodeCBOR(%s) returned an error: %v`, c.hex, err)
+			continue
+		}
+		if len(rest) != 0 {
+			t.Errorf(`decodeCBOR(%s) left %d bytes`, c.hex, len(rest))
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf(`decodeCBOR(%s) = %#v, want %#v`, c.hex, got, c.want)
+		}
+	}
+}
+
+func TestDecodeCBORHalfFloats(t *testing.T) {
+	data, _ := hex.DecodeString("f97c00")
+	got, _, err := decodeCBOR(data)
+	if err != nil || !math.IsInf(got.(float64), 1) {
+		t.Errorf(`decodeCBOR(f97c00) = %v, %v, want +Inf`, got, err)
+	}
+}
+
+func TestDecodeCBORRejects(t *testing.T) {
+	for _, s := range []string{
+		"",                   // no data
+		"5f42010243030405ff", // indefinite length
+		"45010203",           // short byte string
+		"a20102",             // short map
+		"a201020103",         // duplicate key
+		"a1f501",             // boolean key
+		"9bffffffffffffffff", // huge array
+	} {
+		data, _ := hex.DecodeString(s)
+		if _, _, err := decodeCBOR(data); !errors.Is(err, ErrInvalidCBOR) {
+			t.Errorf(`decodeCBOR(%s) error = %v, want ErrInvalidCBOR`, s, err)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+// Package webauthn implements the relying party side of WebAuthn
+// registration and authentication for passkeys and security keys.
+//
+// Only "none" attestation is accepted, which is what browsers send when the
+// relying party does not ask for attestation, as CreationOptions does not.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidResponse = errors.New("webauthn: invalid response")
+	ErrSignCount       = errors.New("webauthn: signature counter did not increase")
+)
+
+// Authenticator data flags.
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+	flagExtensionData    = 0x80
+)
+
+const maxCredentialIDLength = 1023
+
+// Bytes is binary data that travels as unpadded base64url in JSON, as in
+// the WebAuthn JSON serialisation.
+type Bytes []byte
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// RelyingParty is the site credentials are scoped to.
+type RelyingParty struct {
+	// ID is the domain credentials are bound to, such as example.com.
+	ID   string
+	Name string
+	// Origins lists the exact origins ceremonies may run on, such as
+	// https://example.com.
+	Origins []string
+	Timeout time.Duration
+}
+
+// User is the account a credential is being created for. ID must not carry
+// personal information; a random user ID is fine.
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// Credential is what needs to be stored about a registered credential.
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE_Key of the credential.
+	PublicKey []byte
+	SignCount uint32
+}
+
+// NewChallenge returns a random challenge for a ceremony.
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          Bytes  `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type credentialDescriptor struct {
+	Type string `json:"type"`
+	ID   Bytes  `json:"id"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is passed to navigator.credentials.create().
+type CreationOptions struct {
+	PublicKey struct {
+		Challenge              Bytes                  `json:"challenge"`
+		RP                     rpEntity               `json:"rp"`
+		User                   userEntity             `json:"user"`
+		PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+		Timeout                int64                  `json:"timeout"`
+		ExcludeCredentials     []credentialDescriptor `json:"excludeCredentials"`
+		AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+		Attestation            string                 `json:"attestation"`
+	} `json:"publicKey"`
+}
+
+// RequestOptions is passed to navigator.credentials.get().
+type RequestOptions struct {
+	PublicKey struct {
+		Challenge        Bytes                  `json:"challenge"`
+		RPID             string                 `json:"rpId"`
+		Timeout          int64                  `json:"timeout"`
+		AllowCredentials []credentialDescriptor `json:"allowCredentials"`
+		UserVerification string                 `json:"userVerification"`
+	} `json:"publicKey"`
+}
+
+// CreationOptions asks for a discoverable credential for user, other than
+// those in exclude, which the user already has.
+func (rp *RelyingParty) CreationOptions(challenge []byte, user User, exclude [][]byte) CreationOptions {
+	var opts CreationOptions
+	opts.PublicKey.Challenge = challenge
+	opts.PublicKey.RP = rpEntity{ID: rp.ID, Name: rp.Name}
+	opts.PublicKey.User = userEntity{ID: user.ID, Name: user.Name, DisplayName: user.DisplayName}
+	opts.PublicKey.PubKeyCredParams = []credentialParameter{
+		{Type: "public-key", Alg: AlgES256},
+		{Type: "public-key", Alg: AlgEdDSA},
+		{Type: "public-key", Alg: AlgRS256},
+	}
+	opts.PublicKey.Timeout = rp.Timeout.Milliseconds()
+	opts.PublicKey.ExcludeCredentials = descriptors(exclude)
+	opts.PublicKey.AuthenticatorSelection = authenticatorSelection{ResidentKey: "preferred", UserVerification: "preferred"}
+	opts.PublicKey.Attestation = "none"
+	return opts
+}
+
+// RequestOptions asks for an assertion from one of allow, or from any
+// discoverable credential for the site when allow is empty.
+func (rp *RelyingParty) RequestOptions(challenge []byte, allow [][]byte) RequestOptions {
+	var opts RequestOptions
+	opts.PublicKey.Challenge = challenge
+	opts.PublicKey.RPID = rp.ID
+	opts.PublicKey.Timeout = rp.Timeout.Milliseconds()
+	opts.PublicKey.AllowCredentials = descriptors(allow)
+	opts.PublicKey.UserVerification = "preferred"
+	return opts
+}
+
+func descriptors(ids [][]byte) []credentialDescriptor {
+	out := make([]credentialDescriptor, len(ids))
+	for i, id := range ids {
+		out[i] = credentialDescriptor{Type: "public-key", ID: id}
+	}
+	return out
+}
+
+// AttestationResponse is the JSON form of the credential returned by
+// navigator.credentials.create().
+type AttestationResponse struct {
+	RawID    Bytes  `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    Bytes `json:"clientDataJSON"`
+		AttestationObject Bytes `json:"attestationObject"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the credential returned by
+// navigator.credentials.get().
+type AssertionResponse struct {
+	RawID    Bytes  `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    Bytes `json:"clientDataJSON"`
+		AuthenticatorData Bytes `json:"authenticatorData"`
+		Signature         Bytes `json:"signature"`
+		UserHandle        Bytes `json:"userHandle"`
+	} `json:"response"`
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Challenge returns the challenge the client signed, so that the ceremony
+// it belongs to can be looked up before verifying.
+func (r *AttestationResponse) Challenge() ([]byte, error) {
+	return challengeOf(r.Response.ClientDataJSON)
+}
+
+// Challenge returns the challenge the client signed, so that the ceremony
+// it belongs to can be looked up before verifying.
+func (r *AssertionResponse) Challenge() ([]byte, error) {
+	return challengeOf(r.Response.ClientDataJSON)
+}
+
+func challengeOf(clientDataJSON []byte) ([]byte, error) {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return nil, fmt.Errorf("%w: client data: %v", ErrInvalidResponse, err)
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(cd.Challenge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: challenge: %v", ErrInvalidResponse, err)
+	}
+	return challenge, nil
+}
+
+func (rp *RelyingParty) checkClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return fmt.Errorf("%w: client data: %v", ErrInvalidResponse, err)
+	}
+	if cd.Type != ceremony {
+		return fmt.Errorf("%w: client data type %q", ErrInvalidResponse, cd.Type)
+	}
+	got, err := base64.RawURLEncoding.DecodeString(cd.Challenge)
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", ErrInvalidResponse)
+	}
+	if !slices.Contains(rp.Origins, cd.Origin) {
+		return fmt.Errorf("%w: origin %q", ErrInvalidResponse, cd.Origin)
+	}
+	if cd.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin ceremony", ErrInvalidResponse)
+	}
+	return nil
+}
+
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte
+}
+
+func parseAuthenticatorData(data []byte) (authenticatorData, error) {
+	var ad authenticatorData
+	if len(data) < 37 {
+		return ad, fmt.Errorf("%w: authenticator data too short", ErrInvalidResponse)
+	}
+	ad.rpIDHash = data[:32]
+	ad.flags = data[32]
+	ad.signCount = binary.BigEndian.Uint32(data[33:37])
+	rest := data[37:]
+
+	if ad.flags&flagAttestedCredData != 0 {
+		// AAGUID, credential ID length, credential ID, COSE key.
+		if len(rest) < 18 {
+			return ad, fmt.Errorf("%w: attested credential data too short", ErrInvalidResponse)
+		}
+		n := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if n > maxCredentialIDLength || len(rest) < n {
+			return ad, fmt.Errorf("%w: invalid credential ID length", ErrInvalidResponse)
+		}
+		ad.credentialID = rest[:n]
+		rest = rest[n:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return ad, fmt.Errorf("%w: credential public key: %v", ErrInvalidResponse, err)
+		}
+		ad.publicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+	if ad.flags&flagExtensionData != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return ad, fmt.Errorf("%w: extensions: %v", ErrInvalidResponse, err)
+		}
+		rest = after
+	}
+	if len(rest) > 0 {
+		return ad, fmt.Errorf("%w: trailing authenticator data", ErrInvalidResponse)
+	}
+	return ad, nil
+}
+
+func (rp *RelyingParty) checkAuthenticatorData(ad authenticatorData, requireUV bool) error {
+	want := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.rpIDHash, want[:]) {
+		return fmt.Errorf("%w: RP ID hash mismatch", ErrInvalidResponse)
+	}
+	if ad.flags&flagUserPresent == 0 {
+		return fmt.Errorf("%w: user not present", ErrInvalidResponse)
+	}
+	if requireUV && ad.flags&flagUserVerified == 0 {
+		return fmt.Errorf("%w: user not verified", ErrInvalidResponse)
+	}
+	return nil
+}
+
+// VerifyRegistration checks a new credential against the challenge it was
+// created for and returns it for storage.
+func (rp *RelyingParty) VerifyRegistration(resp *AttestationResponse, challenge []byte, requireUV bool) (Credential, error) {
+	if resp.Type != "public-key" {
+		return Credential{}, fmt.Errorf("%w: credential type %q", ErrInvalidResponse, resp.Type)
+	}
+	if err := rp.checkClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return Credential{}, err
+	}
+
+	v, rest, err := decodeCBOR(resp.Response.AttestationObject)
+	if err != nil || len(rest) > 0 {
+		return Credential{}, fmt.Errorf("%w: attestation object: %v", ErrInvalidResponse, err)
+	}
+	obj, _ := v.(map[any]any)
+	format, _ := obj["fmt"].(string)
+	stmt, _ := obj["attStmt"].(map[any]any)
+	rawAuthData, _ := obj["authData"].([]byte)
+	if format != "none" || len(stmt) != 0 {
+		return Credential{}, fmt.Errorf("%w: unsupported attestation format %q", ErrInvalidResponse, format)
+	}
+
+	ad, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+	if err := rp.checkAuthenticatorData(ad, requireUV); err != nil {
+		return Credential{}, err
+	}
+	if ad.credentialID == nil {
+		return Credential{}, fmt.Errorf("%w: no attested credential data", ErrInvalidResponse)
+	}
+	if !bytes.Equal(ad.credentialID, resp.RawID) {
+		return Credential{}, fmt.Errorf("%w: credential ID mismatch", ErrInvalidResponse)
+	}
+	if _, err := parsePublicKey(ad.publicKey); err != nil {
+		return Credential{}, err
+	}
+
+	return Credential{
+		ID:        ad.credentialID,
+		PublicKey: ad.publicKey,
+		SignCount: ad.signCount,
+	}, nil
+}
+
+// AssertionResult is what a successful login tells about the credential.
+type AssertionResult struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// VerifyAssertion checks a login with cred against the challenge it
+// answers. The sign counter must go up unless the authenticator does not
+// keep one, or the credential may have been cloned.
+func (rp *RelyingParty) VerifyAssertion(resp *AssertionResponse, challenge []byte, cred Credential, requireUV bool) (AssertionResult, error) {
+	if resp.Type != "public-key" {
+		return AssertionResult{}, fmt.Errorf("%w: credential type %q", ErrInvalidResponse, resp.Type)
+	}
+	if !bytes.Equal(resp.RawID, cred.ID) {
+		return AssertionResult{}, fmt.Errorf("%w: credential ID mismatch", ErrInvalidResponse)
+	}
+	if err := rp.checkClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return AssertionResult{}, err
+	}
+
+	ad, err := parseAuthenticatorData(resp.Response.AuthenticatorData)
+	if err != nil {
+		return AssertionResult{}, err
+	}
+	if err := rp.checkAuthenticatorData(ad, requireUV); err != nil {
+		return AssertionResult{}, err
+	}
+
+	key, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return AssertionResult{}, err
+	}
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), resp.Response.AuthenticatorData...), clientDataHash[:]...)
+	if err := key.verify(signed, resp.Response.Signature); err != nil {
+		return AssertionResult{}, err
+	}
+
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return AssertionResult{}, ErrSignCount
+	}
+
+	return AssertionResult{
+		SignCount:    ad.signCount,
+		UserVerified: ad.flags&flagUserVerified != 0,
+	}, nil
+}
@@ -0,0 +1,292 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// cborPair keeps map entries in the order the test writes them.
+type cborPair struct {
+	key   any
+	value any
+}
+
+// encodeCBOR writes the values the software authenticator needs.
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 1<<16:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		default:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+	}
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []cborPair:
+		out := head(5, uint64(len(v)))
+		for _, p := range v {
+			out = append(out, encodeCBOR(p.key)...)
+			out = append(out, encodeCBOR(p.value)...)
+		}
+		return out
+	}
+	panic("unsupported type")
+}
+
+// softAuthenticator stands in for a security key or platform
+// authenticator, the way a browser would drive it.
+type softAuthenticator struct {
+	rpID      string
+	origin    string
+	key       crypto.Signer
+	credID    []byte
+	signCount uint32
+	// countless authenticators always report a sign count of zero.
+	countless bool
+	flags     byte
+}
+
+func newSoftAuthenticator(key crypto.Signer) *softAuthenticator {
+	credID := make([]byte, 16)
+	rand.Read(credID)
+	return &softAuthenticator{
+		rpID:   "chirpy.test",
+		origin: "https://chirpy.test",
+		key:    key,
+		credID: credID,
+		flags:  flagUserPresent | flagUserVerified,
+	}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	switch pub := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		pub.X.FillBytes(x)
+		pub.Y.FillBytes(y)
+		return encodeCBOR([]cborPair{{1, ktyEC2}, {3, AlgES256}, {-1, crvP256}, {-2, x}, {-3, y}})
+	case ed25519.PublicKey:
+		return encodeCBOR([]cborPair{{1, ktyOKP}, {3, AlgEdDSA}, {-1, crvEd25519}, {-2, []byte(pub)}})
+	}
+	panic("unsupported key")
+}
+
+func (a *softAuthenticator) clientData(ceremony string, challenge []byte) []byte {
+	data, _ := json.Marshal(clientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.origin,
+	})
+	return data
+}
+
+func (a *softAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	flags := a.flags
+	if attested {
+		flags |= flagAttestedCredData
+	}
+	data = append(data, flags)
+	if !a.countless {
+		a.signCount++
+	}
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credID)))
+		data = append(data, a.credID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+// create returns the credential navigator.credentials.create() would, after
+// a round trip through JSON.
+func (a *softAuthenticator) create(t *testing.T, challenge []byte) *AttestationResponse {
+	var resp AttestationResponse
+	resp.RawID = a.credID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = a.clientData("webauthn.create", challenge)
+	resp.Response.AttestationObject = encodeCBOR([]cborPair{
+		{"fmt", "none"},
+		{"attStmt", []cborPair{}},
+		{"authData", a.authData(true)},
+	})
+	return roundTrip(t, &resp)
+}
+
+// get returns the assertion navigator.credentials.get() would, after a
+// round trip through JSON.
+func (a *softAuthenticator) get(t *testing.T, challenge []byte) *AssertionResponse {
+	var resp AssertionResponse
+	resp.RawID = a.credID
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = a.clientData("webauthn.get", challenge)
+	resp.Response.AuthenticatorData = a.authData(false)
+
+	clientDataHash := sha256.Sum256(resp.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), resp.Response.AuthenticatorData...), clientDataHash[:]...)
+	var sig []byte
+	var err error
+	switch key := a.key.(type) {
+	case *ecdsa.PrivateKey:
+		sum := sha256.Sum256(signed)
+		sig, err = ecdsa.SignASN1(rand.Reader, key, sum[:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(key, signed)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Response.Signature = sig
+	return roundTrip(t, &resp)
+}
+
+func roundTrip[T any](t *testing.T, v *T) *T {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(T)
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func testRP() *RelyingParty {
+	return &RelyingParty{ID: "chirpy.test", Name: "Chirpy", Origins: []string{"https://chirpy.test"}, Timeout: time.Minute}
+}
+
+func register(t *testing.T, rp *RelyingParty, a *softAuthenticator) Credential {
+	challenge, _ := NewChallenge()
+	resp := a.create(t, challenge)
+	got, err := resp.Challenge()
+	if err != nil || string(got) != string(challenge) {
+		t.Fatalf(`Challenge() = %x, %v, want %x`, got, err, challenge)
+	}
+	cred, err := rp.VerifyRegistration(resp, challenge, true)
+	if err != nil {
+		t.Fatalf(`VerifyRegistration returned an error: %v`, err)
+	}
+	return cred
+}
+
+func TestRegisterAndLoginES256(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	a := newSoftAuthenticator(key)
+	rp := testRP()
+
+	cred := register(t, rp, a)
+	if string(cred.ID) != string(a.credID) || cred.SignCount != 1 {
+		t.Errorf(`VerifyRegistration = %+v`, cred)
+	}
+
+	for range 2 {
+		challenge, _ := NewChallenge()
+		result, err := rp.VerifyAssertion(a.get(t, challenge), challenge, cred, true)
+		if err != nil {
+			t.Fatalf(`VerifyAssertion returned an error: %v`, err)
+		}
+		if result.SignCount != a.signCount || !result.UserVerified {
+			t.Errorf(`VerifyAssertion = %+v`, result)
+		}
+		cred.SignCount = result.SignCount
+	}
+
+	// A clone that has fallen behind the stored counter is refused.
+	a.signCount = 1
+	challenge, _ := NewChallenge()
+	if _, err := rp.VerifyAssertion(a.get(t, challenge), challenge, cred, true); !errors.Is(err, ErrSignCount) {
+		t.Errorf(`VerifyAssertion with a stale counter error = %v, want ErrSignCount`, err)
+	}
+}
+
+func TestRegisterAndLoginEdDSAWithoutCounter(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	a := newSoftAuthenticator(key)
+	a.countless = true
+	rp := testRP()
+
+	cred := register(t, rp, a)
+	for range 2 {
+		challenge, _ := NewChallenge()
+		if _, err := rp.VerifyAssertion(a.get(t, challenge), challenge, cred, true); err != nil {
+			t.Fatalf(`VerifyAssertion returned an error: %v`, err)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rp := testRP()
+	cred := register(t, rp, newSoftAuthenticator(key))
+
+	cases := map[string]func(a *softAuthenticator, challenge []byte) (*AssertionResponse, []byte){
+		"other origin": func(a *softAuthenticator, challenge []byte) (*AssertionResponse, []byte) {
+			a.origin = "https://chirpy.evil"
+			return a.get(t, challenge), challenge
+		},
+		"other RP ID": func(a *softAuthenticator, challenge []byte) (*AssertionResponse, []byte) {
+			a.rpID = "chirpy.evil"
+			return a.get(t, challenge), challenge
+		},
+		"other challenge": func(a *softAuthenticator, challenge []byte) (*AssertionResponse, []byte) {
+			other, _ := NewChallenge()
+			return a.get(t, other), challenge
+		},
+		"user not verified": func(a *softAuthenticator, challenge []byte) (*AssertionResponse, []byte) {
+			a.flags = flagUserPresent
+			return a.get(t, challenge), challenge
+		},
+		"tampered data": func(a *softAuthenticator, challenge []byte) (*AssertionResponse, []byte) {
+			resp := a.get(t, challenge)
+			resp.Response.AuthenticatorData[36]++
+			return resp, challenge
+		},
+	}
+	for name, makeResp := range cases {
+		a := newSoftAuthenticator(key)
+		a.credID = cred.ID
+		a.signCount = 10
+		challenge, _ := NewChallenge()
+		resp, challenge := makeResp(a, challenge)
+		if _, err := rp.VerifyAssertion(resp, challenge, cred, true); err == nil {
+			t.Errorf(`VerifyAssertion accepted an assertion with %s`, name)
+		}
+	}
+}
+
+func TestParsePublicKeyRejectsPointOffCurve(t *testing.T) {
+	x := new(big.Int).SetInt64(1).FillBytes(make([]byte, 32))
+	y := new(big.Int).SetInt64(2).FillBytes(make([]byte, 32))
+	cose := encodeCBOR([]cborPair{{1, ktyEC2}, {3, AlgES256}, {-1, crvP256}, {-2, x}, {-3, y}})
+	if _, err := parsePublicKey(cose); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf(`parsePublicKey of a point off the curve error = %v, want ErrUnsupportedKey`, err)
+	}
+}
@@ -0,0 +1,293 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message opcodes as defined in RFC 6455.
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// Close codes as defined in RFC 6455.
+const (
+	CloseNormalClosure   = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseTryAgainLater   = 1013
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+	ErrReadLimit    = errors.New("websocket: read limit exceeded")
+	ErrProtocol     = errors.New("websocket: protocol error")
+)
+
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Reason)
+}
+
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	wmu         sync.Mutex
+	readLimit   int64
+	pongHandler func()
+	closeSent   bool
+}
+
+func headerContains(headers http.Header, name, token string) bool {
+	for _, value := range headers.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Upgrade performs the server side of the opening handshake and takes over
+// the underlying connection. On failure an HTTP error has already been sent.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" ||
+		key == "" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return nil, ErrBadHandshake
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:      netConn,
+		br:        rw.Reader,
+		readLimit: 1 << 16,
+	}, nil
+}
+
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetPongHandler sets the function called when a pong frame is received.
+// It runs on the goroutine calling ReadMessage.
+func (c *Conn) SetPongHandler(h func()) {
+	c.pongHandler = h
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.br, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+	if header[0]&0x70 != 0 || header[1]&0x80 == 0 {
+		// Reserved bits must be clear and client frames must be masked.
+		err = ErrProtocol
+		return
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if opcode >= CloseMessage && (length > 125 || !fin) {
+		err = ErrProtocol
+		return
+	}
+	if length < 0 || length > c.readLimit {
+		err = ErrReadLimit
+		return
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+// ReadMessage returns the next text or binary message. Ping frames are
+// answered automatically, pong frames invoke the pong handler and a close
+// frame is echoed back and reported as a *CloseError.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var opcode int
+	var message []byte
+	for {
+		fin, frameOpcode, payload, err := c.readFrame()
+		if err != nil {
+			if err == ErrReadLimit {
+				c.WriteClose(CloseMessageTooBig, "")
+			} else if err == ErrProtocol {
+				c.WriteClose(CloseProtocolError, "")
+			}
+			return 0, nil, err
+		}
+
+		switch frameOpcode {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				c.pongHandler()
+			}
+			continue
+		case CloseMessage:
+			closeErr := &CloseError{Code: CloseNormalClosure}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.WriteClose(closeErr.Code, "")
+			return 0, nil, closeErr
+		case 0:
+			if opcode == 0 {
+				return 0, nil, ErrProtocol
+			}
+		case TextMessage, BinaryMessage:
+			if opcode != 0 {
+				return 0, nil, ErrProtocol
+			}
+			opcode = frameOpcode
+		default:
+			c.WriteClose(CloseProtocolError, "")
+			return 0, nil, ErrProtocol
+		}
+
+		if int64(len(message)+len(payload)) > c.readLimit {
+			c.WriteClose(CloseMessageTooBig, "")
+			return 0, nil, ErrReadLimit
+		}
+		message = append(message, payload...)
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+// WriteMessage sends a single unfragmented frame. It is safe to call from
+// multiple goroutines.
+func (c *Conn) WriteMessage(opcode int, data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.writeFrame(opcode, data, time.Now().Add(10*time.Second))
+}
+
+func (c *Conn) writeFrame(opcode int, data []byte, deadline time.Time) error {
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+
+	frame := make([]byte, 0, len(data)+10)
+	frame = append(frame, 0x80|byte(opcode))
+	switch {
+	case len(data) < 126:
+		frame = append(frame, byte(len(data)))
+	case len(data) <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(data)))
+	}
+	frame = append(frame, data...)
+
+	c.conn.SetWriteDeadline(deadline)
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// WriteClose sends a close frame with the given status code and reason.
+// Further writes fail.
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	return c.writeFrame(CloseMessage, payload, time.Now().Add(time.Second))
+}
@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func writeClientFrame(t *testing.T, conn net.Conn, opcode int, fin bool, payload []byte) {
+	t.Helper()
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf(`writing frame returned an error: %v`, err)
+	}
+}
+
+func readServerFrame(t *testing.T, br *bufio.Reader) (int, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		t.Fatalf(`reading frame returned an error: %v`, err)
+	}
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	io.ReadFull(br, payload)
+	return int(header[0] & 0x0f), payload
+}
+
+func TestEcho(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			opcode, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(opcode, msg)
+		}
+	}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf(`net.Dial returned an error: %v`, err)
+	}
+	defer conn.Close()
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf(`reading handshake response returned an error: %v`, err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf(`handshake status = %d, want %d`, res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if got, want := res.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf(`Sec-WebSocket-Accept = %q, want %q`, got, want)
+	}
+
+	// A fragmented text message with a ping in between.
+	writeClientFrame(t, conn, TextMessage, false, []byte("hello "))
+	writeClientFrame(t, conn, PingMessage, true, []byte("p"))
+	writeClientFrame(t, conn, 0, true, []byte("world"))
+
+	opcode, payload := readServerFrame(t, br)
+	if opcode != PongMessage || string(payload) != "p" {
+		t.Errorf(`got frame (%d, %q), want pong "p"`, opcode, payload)
+	}
+	opcode, payload = readServerFrame(t, br)
+	if opcode != TextMessage || string(payload) != "hello world" {
+		t.Errorf(`got frame (%d, %q), want text "hello world"`, opcode, payload)
+	}
+
+	writeClientFrame(t, conn, CloseMessage, true, binary.BigEndian.AppendUint16(nil, CloseNormalClosure))
+	opcode, payload = readServerFrame(t, br)
+	if opcode != CloseMessage || binary.BigEndian.Uint16(payload) != CloseNormalClosure {
+		t.Errorf(`got frame (%d, %v), want normal close`, opcode, payload)
+	}
+}
+
+func TestBadHandshake(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	if _, err := Upgrade(rec, req); err != ErrBadHandshake {
+		t.Fatalf(`Upgrade without headers returned %v, want %v`, err, ErrBadHandshake)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf(`status = %d, want %d`, rec.Code, http.StatusBadRequest)
+	}
+}
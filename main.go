@@ -1,13 +1,25 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/api"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/auth"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/blobstore"
 	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/database"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/mail"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/media"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/pubsub"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/unfurl"
+	"github.com/danilogalisteu/bd-07-gp-chirpy/internal/webauthn"
 
 	"github.com/joho/godotenv"
 
@@ -17,7 +29,7 @@ import (
 func middlewareCors(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, PATCH, DELETE")
 		w.Header().Set("Access-Control-Allow-Headers", "*")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -42,28 +54,221 @@ func main() {
 
 	dbQueries := database.New(db)
 
+	broker := pubsub.NewBroker()
+	go broker.Listen(context.Background(), dbURL, dbQueries)
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "data"
+	}
+	blobs, err := blobstore.NewLocalStore(mediaDir)
+	if err != nil {
+		log.Println("Error opening media storage:", err)
+		os.Exit(1)
+	}
+
+	trashRetention := 30 * 24 * time.Hour
+	if v := os.Getenv("TRASH_RETENTION"); v != "" {
+		trashRetention, err = time.ParseDuration(v)
+		if err != nil {
+			log.Println("Invalid TRASH_RETENTION:", err)
+			os.Exit(1)
+		}
+	}
+
+	// Without an SMTP server, mail is written to the log.
+	var mailer mail.Mailer = mail.LogMailer{}
+	if addr := os.Getenv("SMTP_ADDR"); addr != "" {
+		mailer = mail.SMTPMailer{
+			Addr:     addr,
+			From:     os.Getenv("MAIL_FROM"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+
+	var hasher auth.PasswordHasher
+	switch os.Getenv("PASSWORD_HASHER") {
+	case "", "argon2id":
+		argon2id := auth.DefaultArgon2id
+		if v := os.Getenv("ARGON2_PARAMS"); v != "" {
+			argon2id, err = auth.ParseArgon2idParams(v)
+			if err != nil {
+				log.Println("Invalid ARGON2_PARAMS:", err)
+				os.Exit(1)
+			}
+		}
+		hasher = argon2id
+	case "bcrypt":
+		hasher = auth.DefaultBcrypt
+	default:
+		log.Println("Invalid PASSWORD_HASHER:", os.Getenv("PASSWORD_HASHER"))
+		os.Exit(1)
+	}
+
+	var breaches auth.BreachChecker
+	if dir := os.Getenv("BREACHED_PASSWORDS_DIR"); dir != "" {
+		rangeDir, err := auth.NewRangeDir(dir, 1)
+		if err != nil {
+			log.Println("Error opening breached passwords:", err)
+			os.Exit(1)
+		}
+		if rangeDir.Prefixes < auth.RangePrefixes {
+			log.Printf("WARNING: %s holds %d of %d range files; passwords in the missing ranges are not checked for breaches", dir, rangeDir.Prefixes, auth.RangePrefixes)
+		}
+		breaches = rangeDir
+	}
+
+	rp := &webauthn.RelyingParty{
+		ID:      "localhost",
+		Name:    "Chirpy",
+		Origins: []string{"http://localhost:8080"},
+		Timeout: 5 * time.Minute,
+	}
+	if v := os.Getenv("WEBAUTHN_RP_ID"); v != "" {
+		rp.ID = v
+	}
+	if v := os.Getenv("WEBAUTHN_ORIGINS"); v != "" {
+		rp.Origins = strings.Split(v, ",")
+	}
+
+	wsOrigins := []string{"http://localhost:8080"}
+	if v := os.Getenv("WS_ORIGINS"); v != "" {
+		wsOrigins = strings.Split(v, ",")
+	}
+
 	apiCfg := api.ApiConfig{
 		JwtSecret:      os.Getenv("JWT_SECRET"),
 		PolkaApiKey:    os.Getenv("POLKA_KEY"),
+		Platform:       os.Getenv("PLATFORM"),
 		FileserverHits: 0,
+		DB:             db,
 		DbQueries:      dbQueries,
+		Broker:         broker,
+		Blobs:          blobs,
+		MediaPool:      media.NewPool(runtime.GOMAXPROCS(0), media.DefaultOptions),
+		Unfurler:       unfurl.NewHTTPFetcher(unfurl.DefaultOptions),
+		Mailer:         mailer,
+		Hasher:         hasher,
+		Breaches:       breaches,
+		WebAuthn:       rp,
+		TrashRetention: trashRetention,
+		WsOrigins:      wsOrigins,
 	}
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import-media":
+			err = importMedia(&apiCfg, os.Args[2:])
+		case "promote-admin":
+			err = promoteAdmin(&apiCfg, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			log.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	go apiCfg.RunTrendAggregator(context.Background(), time.Minute)
+	go apiCfg.RunUnfurler(context.Background(), 5*time.Second)
+	go apiCfg.RunScheduler(context.Background(), 10*time.Second)
+	go apiCfg.RunPurger(context.Background(), time.Hour)
+	go apiCfg.RunExporter(context.Background(), 5*time.Second)
+
 	mux := http.NewServeMux()
 	mux.Handle("GET /app/", apiCfg.MiddlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(".")))))
 	mux.HandleFunc("GET /api/healthz", api.HealthHandler)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.MiddlewareMetricsCount)
+	mux.Handle("GET /admin/metrics", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.MiddlewareMetricsCount)))
 	mux.HandleFunc("POST /admin/reset", apiCfg.MiddlewareMetricsReset)
+	mux.Handle("GET /admin/trends/hidden", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.GetHiddenHashtags)))
+	mux.Handle("POST /admin/trends/hidden", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.HideHashtag)))
+	mux.Handle("DELETE /admin/trends/hidden/{tag}", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.UnhideHashtag)))
+	mux.Handle("GET /admin/users", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.GetAdminUsers)))
+	mux.Handle("GET /admin/users/{userID}", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.GetAdminUser)))
+	mux.Handle("GET /admin/users/{userID}/chirps", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.GetAdminUserChirps)))
+	mux.Handle("GET /admin/users/{userID}/sessions", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.GetAdminUserSessions)))
+	mux.Handle("PUT /admin/users/{userID}/role", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.SetUserRole)))
+	mux.Handle("POST /admin/users/{userID}/suspension", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.SuspendUser)))
+	mux.Handle("DELETE /admin/users/{userID}/suspension", apiCfg.MiddlewareRole(api.RoleModerator, http.HandlerFunc(apiCfg.UnsuspendUser)))
+	mux.Handle("POST /admin/users/{userID}/ban", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.BanUser)))
+	mux.Handle("DELETE /admin/users/{userID}/ban", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.UnbanUser)))
+	mux.Handle("POST /admin/users/{userID}/password-reset", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.ForcePasswordReset)))
+	mux.Handle("PUT /admin/users/{userID}/chirpy-red", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.SetChirpyRed)))
+	mux.Handle("GET /admin/audit", apiCfg.MiddlewareAdmin(http.HandlerFunc(apiCfg.GetAuditLog)))
 	mux.HandleFunc("POST /api/users", apiCfg.CreateUser)
 	mux.HandleFunc("PUT /api/users", apiCfg.UpdateUser)
+	mux.HandleFunc("PATCH /api/users", apiCfg.UpdateUser)
+	mux.HandleFunc("POST /api/users/email/confirm", apiCfg.ConfirmEmail)
+	mux.HandleFunc("POST /api/users/password/reset", apiCfg.ResetPassword)
+	mux.HandleFunc("GET /api/users/2fa", apiCfg.GetTwoFactor)
+	mux.HandleFunc("POST /api/users/2fa", apiCfg.EnrollTwoFactor)
+	mux.HandleFunc("DELETE /api/users/2fa", apiCfg.DisableTwoFactor)
+	mux.HandleFunc("POST /api/users/2fa/verify", apiCfg.VerifyTwoFactor)
+	mux.HandleFunc("POST /api/users/2fa/recovery-codes", apiCfg.RegenerateRecoveryCodes)
+	mux.HandleFunc("DELETE /api/users", apiCfg.DeleteUser)
+	mux.HandleFunc("POST /api/users/export", apiCfg.CreateExport)
+	mux.HandleFunc("GET /api/users/export", apiCfg.GetExport)
+	mux.HandleFunc("GET /api/users/export/archive", apiCfg.GetExportArchive)
+	mux.HandleFunc("PATCH /api/users/me", apiCfg.UpdateProfile)
+	mux.HandleFunc("GET /api/users/{handleOrID}", apiCfg.GetProfile)
+	mux.HandleFunc("POST /api/users/{handleOrID}/follow", apiCfg.FollowUser)
+	mux.HandleFunc("DELETE /api/users/{handleOrID}/follow", apiCfg.UnfollowUser)
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.UpdateUserRed)
 	mux.HandleFunc("POST /api/login", apiCfg.GetUser)
+	mux.HandleFunc("POST /api/login/mfa", apiCfg.LoginMFA)
+	mux.HandleFunc("POST /api/webauthn/register/begin", apiCfg.BeginPasskeyRegistration)
+	mux.HandleFunc("POST /api/webauthn/register/finish", apiCfg.FinishPasskeyRegistration)
+	mux.HandleFunc("POST /api/webauthn/login/begin", apiCfg.BeginPasskeyLogin)
+	mux.HandleFunc("POST /api/webauthn/login/finish", apiCfg.FinishPasskeyLogin)
+	mux.HandleFunc("GET /api/webauthn/credentials", apiCfg.GetPasskeys)
+	mux.HandleFunc("DELETE /api/webauthn/credentials/{credentialID}", apiCfg.DeletePasskey)
+	mux.HandleFunc("GET /api/oauth/clients", apiCfg.GetOAuthClients)
+	mux.HandleFunc("POST /api/oauth/clients", apiCfg.CreateOAuthClient)
+	mux.HandleFunc("DELETE /api/oauth/clients/{clientID}", apiCfg.DeleteOAuthClient)
+	mux.HandleFunc("GET /api/oauth/authorize", apiCfg.GetOAuthAuthorization)
+	mux.HandleFunc("POST /api/oauth/authorize", apiCfg.Authorize)
+	mux.HandleFunc("POST /api/oauth/token", apiCfg.IssueOAuthToken)
+	mux.HandleFunc("POST /api/oauth/introspect", apiCfg.IntrospectOAuthToken)
+	mux.HandleFunc("POST /api/oauth/revoke", apiCfg.RevokeOAuthToken)
+	mux.HandleFunc("GET /api/oauth/consents", apiCfg.GetOAuthConsents)
+	mux.HandleFunc("DELETE /api/oauth/consents/{clientID}", apiCfg.DeleteOAuthConsent)
+	mux.HandleFunc("GET /api/keys", apiCfg.GetAPIKeys)
+	mux.HandleFunc("POST /api/keys", apiCfg.CreateAPIKey)
+	mux.HandleFunc("DELETE /api/keys/{keyID}", apiCfg.RevokeAPIKey)
 	mux.HandleFunc("POST /api/refresh", apiCfg.GetToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.UpdateToken)
 	mux.HandleFunc("POST /api/chirps", apiCfg.CreateChirp)
 	mux.HandleFunc("GET /api/chirps", apiCfg.GetChirps)
+	mux.HandleFunc("GET /api/chirps/stream", apiCfg.StreamChirps)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.SearchChirps)
+	mux.HandleFunc("GET /api/chirps/scheduled", apiCfg.GetScheduledChirps)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/schedule", apiCfg.RescheduleChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/schedule", apiCfg.CancelScheduledChirp)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.GetChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.DeleteChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.RestoreChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.GetChirpThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", apiCfg.LikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.UnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.Rechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.UndoRechirp)
+	mux.HandleFunc("GET /api/drafts", apiCfg.GetDrafts)
+	mux.HandleFunc("POST /api/drafts", apiCfg.CreateDraft)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.GetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.UpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.DeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.PublishDraft)
+	mux.HandleFunc("POST /api/media", apiCfg.UploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.GetMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}/{variant}", apiCfg.GetMediaVariant)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.GetHashtagChirps)
+	mux.HandleFunc("GET /api/trends", apiCfg.GetTrends)
+	mux.HandleFunc("GET /api/users/{userID}/mentions", apiCfg.GetUserMentions)
+	mux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.GetUserLikes)
+	mux.HandleFunc("GET /api/ws", apiCfg.ServeWebSocket)
 
 	corsMux := middlewareCors(mux)
 	server := http.Server{
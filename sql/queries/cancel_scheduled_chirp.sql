@@ -0,0 +1,3 @@
+-- name: CancelScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND user_id = $2 AND status = 'scheduled';
\ No newline at end of file
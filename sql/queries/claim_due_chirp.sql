@@ -0,0 +1,5 @@
+-- name: ClaimDueChirp :one
+SELECT *
+FROM chirps
+WHERE id = $1 AND status = 'scheduled' AND deleted_at IS NULL
+FOR UPDATE SKIP LOCKED;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: ClaimExport :one
+UPDATE exports
+SET status = 'running', started_at = sqlc.arg(started_at)::timestamp, updated_at = sqlc.arg(started_at)::timestamp
+WHERE id = (
+    SELECT id FROM exports
+    WHERE status = 'pending' OR (status = 'running' AND started_at < sqlc.arg(stale_before)::timestamp)
+    ORDER BY created_at ASC
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: CompleteExport :exec
+UPDATE exports
+SET status = 'done', completed_at = $2, updated_at = $2, storage_key = $3, size_bytes = $4
+WHERE id = $1;
\ No newline at end of file
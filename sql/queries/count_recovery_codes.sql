@@ -0,0 +1,2 @@
+-- name: CountRecoveryCodes :one
+SELECT count(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (id, actor_id, action, target_user_id, reason, details, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
\ No newline at end of file
@@ -1,10 +1,17 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, root_id, quote_of, preview_url, status, publish_at, published_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    CASE WHEN $10 = 'published' THEN $2::timestamp END
 )
 RETURNING *;
\ No newline at end of file
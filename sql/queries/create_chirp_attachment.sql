@@ -0,0 +1,7 @@
+-- name: CreateChirpAttachment :exec
+INSERT INTO chirp_attachments (chirp_id, media_id, position)
+VALUES (
+    $1,
+    $2,
+    $3
+);
\ No newline at end of file
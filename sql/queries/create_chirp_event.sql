@@ -0,0 +1,11 @@
+-- name: CreateChirpEvent :one
+INSERT INTO chirp_events (created_at, event_type, chirp_id, user_id, body, rechirp_of)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
\ No newline at end of file
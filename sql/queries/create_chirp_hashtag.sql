@@ -0,0 +1,9 @@
+-- name: CreateChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at, start_index, end_index)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
\ No newline at end of file
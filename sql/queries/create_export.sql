@@ -0,0 +1,10 @@
+-- name: CreateExport :one
+INSERT INTO exports (id, created_at, updated_at, user_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id) WHERE status IN ('pending', 'running') DO NOTHING
+RETURNING *;
\ No newline at end of file
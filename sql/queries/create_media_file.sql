@@ -0,0 +1,14 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files (id, created_at, user_id, content_type, size_bytes, width, height, storage_key, blurhash)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: CreateMediaVariant :exec
+INSERT INTO media_variants (media_id, name, content_type, size_bytes, width, height, storage_key)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+);
\ No newline at end of file
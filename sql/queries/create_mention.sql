@@ -0,0 +1,9 @@
+-- name: CreateMention :exec
+INSERT INTO mentions (chirp_id, user_id, created_at, start_index, end_index)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (id, user_id, created_at, expires_at)
+VALUES ($1, $2, $3, $4);
\ No newline at end of file
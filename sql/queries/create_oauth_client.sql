@@ -0,0 +1,11 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (id, secret_hash, name, redirect_uris, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- name: CreateOAuthCode :exec
+INSERT INTO oauth_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+);
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- name: CreateOAuthGrant :one
+INSERT INTO oauth_grants (id, refresh_token_hash, client_id, user_id, scopes, created_at, updated_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $6,
+    $7
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of, published_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    '',
+    $4,
+    $5,
+    $2
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+RETURNING *;
\ No newline at end of file
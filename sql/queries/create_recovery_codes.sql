@@ -0,0 +1,3 @@
+-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (user_id, code_hash, created_at)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(code_hashes)::text[]), sqlc.arg(created_at)::timestamp;
\ No newline at end of file
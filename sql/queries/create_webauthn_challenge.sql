@@ -0,0 +1,12 @@
+-- name: CreateWebAuthnChallenge :execrows
+INSERT INTO webauthn_challenges (challenge, ceremony, user_id, created_at, expires_at)
+SELECT
+    sqlc.arg(challenge)::bytea,
+    sqlc.arg(ceremony)::text,
+    sqlc.narg(user_id)::uuid,
+    sqlc.arg(created_at)::timestamp,
+    sqlc.arg(expires_at)::timestamp
+WHERE (
+    SELECT count(*) FROM webauthn_challenges
+    WHERE ceremony = sqlc.arg(ceremony)::text AND expires_at > sqlc.arg(created_at)::timestamp
+) < sqlc.arg(max_pending)::int;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials (id, user_id, public_key, sign_count, name, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: DeleteChirpEventsBefore :execrows
+DELETE FROM chirp_events
+WHERE created_at < $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: DeleteEmailChange :exec
+DELETE FROM email_changes WHERE user_id = $1;
\ No newline at end of file
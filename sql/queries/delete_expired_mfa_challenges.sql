@@ -0,0 +1,2 @@
+-- name: DeleteExpiredMFAChallenges :execrows
+DELETE FROM mfa_challenges WHERE expires_at < $1;
\ No newline at end of file
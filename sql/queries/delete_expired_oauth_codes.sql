@@ -0,0 +1,3 @@
+-- name: DeleteExpiredOAuthCodes :execrows
+DELETE FROM oauth_codes
+WHERE expires_at < $1;
\ No newline at end of file
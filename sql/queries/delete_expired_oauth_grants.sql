@@ -0,0 +1,3 @@
+-- name: DeleteExpiredOAuthGrants :execrows
+DELETE FROM oauth_grants
+WHERE expires_at < $1;
\ No newline at end of file
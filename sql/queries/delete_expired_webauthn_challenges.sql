@@ -0,0 +1,2 @@
+-- name: DeleteExpiredWebAuthnChallenges :execrows
+DELETE FROM webauthn_challenges WHERE expires_at < $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: DeleteExport :exec
+DELETE FROM exports WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: DeleteHashtagRollups :exec
+DELETE FROM hashtag_rollups WHERE bucket < $1;
\ No newline at end of file
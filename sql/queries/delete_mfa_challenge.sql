@@ -0,0 +1,2 @@
+-- name: DeleteMFAChallenge :execrows
+DELETE FROM mfa_challenges WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: DeleteOAuthConsent :execrows
+DELETE FROM oauth_consents
+WHERE user_id = $1 AND client_id = $2;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: DeletePasswordReset :exec
+DELETE FROM password_resets
+WHERE user_id = $1;
\ No newline at end of file
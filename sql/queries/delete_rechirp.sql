@@ -0,0 +1,4 @@
+-- name: DeleteRechirp :one
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of = $2
+RETURNING *;
\ No newline at end of file
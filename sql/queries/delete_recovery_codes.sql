@@ -0,0 +1,2 @@
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes WHERE user_id = $1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: DeleteTOTPCredential :exec
+DELETE FROM totp_credentials WHERE user_id = $1;
\ No newline at end of file
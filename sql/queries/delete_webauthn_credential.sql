@@ -0,0 +1,2 @@
+-- name: DeleteWebAuthnCredential :execrows
+DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: EnableTOTPCredential :execrows
+UPDATE totp_credentials
+SET enabled_at = $2, last_step = $3
+WHERE user_id = $1 AND enabled_at IS NULL AND last_step < $3;
\ No newline at end of file
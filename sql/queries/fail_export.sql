@@ -0,0 +1,4 @@
+-- name: FailExport :exec
+UPDATE exports
+SET status = 'failed', completed_at = $2, updated_at = $2
+WHERE id = $1;
\ No newline at end of file
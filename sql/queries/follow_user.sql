@@ -0,0 +1,8 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followed_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: GetAllChirpsByUser :many
+SELECT *
+FROM chirps
+WHERE user_id = $1
+ORDER BY created_at ASC;
\ No newline at end of file
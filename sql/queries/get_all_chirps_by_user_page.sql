@@ -0,0 +1,6 @@
+-- name: GetAllChirpsByUserPage :many
+SELECT *
+FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: GetAPIKeyByHash :one
+SELECT api_keys.*
+FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.key_hash = $1 AND users.deleted_at IS NULL;
\ No newline at end of file
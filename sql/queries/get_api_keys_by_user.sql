@@ -0,0 +1,4 @@
+-- name: GetAPIKeysByUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetAttachmentsByChirps :many
+SELECT chirp_attachments.chirp_id, media_files.*
+FROM chirp_attachments
+JOIN media_files ON media_files.id = chirp_attachments.media_id
+WHERE chirp_attachments.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_attachments.chirp_id, chirp_attachments.position;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: GetAuditLog :many
+SELECT *
+FROM audit_log
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id))
+    AND (sqlc.narg(target_user_id)::uuid IS NULL OR target_user_id = sqlc.narg(target_user_id))
+    AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT chirps.in_reply_to AS id, 1 AS depth
+    FROM chirps
+    WHERE chirps.id = sqlc.arg(id)
+    UNION ALL
+    SELECT chirps.in_reply_to, ancestors.depth + 1
+    FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.id
+    WHERE chirps.in_reply_to IS NOT NULL
+)
+SELECT chirps.*
+FROM ancestors
+JOIN chirps ON chirps.id = ancestors.id
+ORDER BY ancestors.depth DESC;
\ No newline at end of file
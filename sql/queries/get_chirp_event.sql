@@ -0,0 +1,2 @@
+-- name: GetChirpEvent :one
+SELECT * FROM chirp_events WHERE id = $1 LIMIT 1;
\ No newline at end of file
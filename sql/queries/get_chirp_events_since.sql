@@ -0,0 +1,6 @@
+-- name: GetChirpEventsSince :many
+SELECT *
+FROM chirp_events
+WHERE id > $1
+ORDER BY id ASC
+LIMIT $2;
\ No newline at end of file
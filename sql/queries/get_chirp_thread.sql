@@ -0,0 +1,16 @@
+-- name: GetChirpThread :many
+WITH RECURSIVE thread AS (
+    SELECT chirps.id, 0 AS depth
+    FROM chirps
+    WHERE chirps.id = sqlc.arg(id) AND chirps.status = 'published'
+    UNION ALL
+    SELECT chirps.id, thread.depth + 1
+    FROM chirps
+    JOIN thread ON chirps.in_reply_to = thread.id
+    WHERE thread.depth < sqlc.arg(max_depth) AND chirps.status = 'published'
+)
+SELECT sqlc.embed(chirps), thread.depth,
+    EXISTS (SELECT 1 FROM chirps AS replies WHERE replies.in_reply_to = chirps.id AND replies.status = 'published') AS has_replies
+FROM thread
+JOIN chirps ON chirps.id = thread.id
+ORDER BY thread.depth ASC, chirps.published_at ASC;
\ No newline at end of file
@@ -1,4 +1,5 @@
 -- name: GetChirps :many
 SELECT *
 FROM chirps
-ORDER BY created_at ASC;
\ No newline at end of file
+WHERE deleted_at IS NULL AND status = 'published'
+ORDER BY published_at ASC;
\ No newline at end of file
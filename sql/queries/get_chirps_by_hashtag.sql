@@ -0,0 +1,10 @@
+-- name: GetChirpsByHashtag :many
+SELECT *
+FROM chirps
+WHERE id IN (
+    SELECT chirp_hashtags.chirp_id
+    FROM chirp_hashtags
+    JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+    WHERE hashtags.tag = $1
+) AND deleted_at IS NULL AND status = 'published'
+ORDER BY published_at ASC;
\ No newline at end of file
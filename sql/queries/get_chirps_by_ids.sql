@@ -0,0 +1,4 @@
+-- name: GetChirpsByIDs :many
+SELECT *
+FROM chirps
+WHERE id = ANY(sqlc.arg(chirp_ids)::uuid[]);
\ No newline at end of file
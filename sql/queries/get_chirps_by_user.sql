@@ -1,5 +1,5 @@
 -- name: GetChirpsByUser :many
 SELECT *
 FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
\ No newline at end of file
+WHERE user_id = $1 AND deleted_at IS NULL AND status = 'published'
+ORDER BY published_at ASC;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetChirpsLikedByUser :many
+SELECT chirps.*
+FROM chirps
+JOIN likes ON likes.chirp_id = chirps.id
+WHERE likes.user_id = $1 AND chirps.deleted_at IS NULL AND chirps.status = 'published'
+ORDER BY likes.created_at DESC;
\ No newline at end of file
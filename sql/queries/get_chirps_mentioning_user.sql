@@ -0,0 +1,9 @@
+-- name: GetChirpsMentioningUser :many
+SELECT *
+FROM chirps
+WHERE id IN (
+    SELECT mentions.chirp_id
+    FROM mentions
+    WHERE mentions.user_id = $1
+) AND deleted_at IS NULL AND status = 'published'
+ORDER BY published_at ASC;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: GetDraft :one
+SELECT *
+FROM drafts
+WHERE id = $1 AND user_id = $2;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetDueChirps :many
+SELECT *
+FROM chirps
+WHERE status = 'scheduled' AND publish_at <= $1 AND deleted_at IS NULL
+ORDER BY publish_at ASC
+LIMIT $2;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: GetEmailChangeByToken :one
+SELECT * FROM email_changes WHERE token_hash = $1 LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: GetExpiredExports :many
+SELECT *
+FROM exports
+WHERE created_at < $1
+    OR user_id IN (SELECT id FROM users WHERE deleted_at IS NOT NULL);
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetHashtagsByChirps :many
+SELECT chirp_hashtags.chirp_id, hashtags.tag, chirp_hashtags.start_index, chirp_hashtags.end_index
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE chirp_hashtags.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_hashtags.start_index ASC;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: GetHiddenHashtags :many
+SELECT hashtags.tag, hidden_hashtags.created_at
+FROM hidden_hashtags
+JOIN hashtags ON hashtags.id = hidden_hashtags.hashtag_id
+ORDER BY hashtags.tag ASC;
\ No newline at end of file
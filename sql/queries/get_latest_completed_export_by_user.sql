@@ -0,0 +1,6 @@
+-- name: GetLatestCompletedExportByUser :one
+SELECT *
+FROM exports
+WHERE user_id = $1 AND status = 'done'
+ORDER BY created_at DESC
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetLatestExportByUser :one
+SELECT *
+FROM exports
+WHERE user_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
\ No newline at end of file
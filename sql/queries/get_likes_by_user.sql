@@ -0,0 +1,5 @@
+-- name: GetLikesByUser :many
+SELECT *
+FROM likes
+WHERE user_id = $1
+ORDER BY created_at ASC;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: GetLinkPreviewsByURLs :many
+SELECT *
+FROM link_previews
+WHERE url = ANY(sqlc.arg(urls)::text[]) AND ok;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: GetMediaFile :one
+SELECT * FROM media_files
+WHERE id = $1
+    AND NOT EXISTS (SELECT 1 FROM users WHERE users.id = media_files.user_id AND users.deleted_at IS NOT NULL);
\ No newline at end of file
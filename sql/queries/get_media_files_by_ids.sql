@@ -0,0 +1,4 @@
+-- name: GetMediaFilesByIDs :many
+SELECT *
+FROM media_files
+WHERE id = ANY(sqlc.arg(media_ids)::uuid[]);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: GetMediaFilesByUser :many
+SELECT *
+FROM media_files
+WHERE user_id = $1
+ORDER BY created_at ASC;
\ No newline at end of file
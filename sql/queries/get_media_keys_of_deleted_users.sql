@@ -0,0 +1,5 @@
+-- name: GetMediaKeysOfDeletedUsers :many
+SELECT media_files.storage_key
+FROM media_files
+JOIN users ON users.id = media_files.user_id
+WHERE users.deleted_at < $1;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: GetMediaVariant :one
+SELECT * FROM media_variants
+WHERE media_id = $1 AND name = $2
+    AND NOT EXISTS (
+        SELECT 1 FROM media_files
+        JOIN users ON users.id = media_files.user_id
+        WHERE media_files.id = media_variants.media_id AND users.deleted_at IS NOT NULL
+    );
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetMediaVariantKeysOfDeletedUsers :many
+SELECT media_variants.storage_key
+FROM media_variants
+JOIN media_files ON media_files.id = media_variants.media_id
+JOIN users ON users.id = media_files.user_id
+WHERE users.deleted_at < $1;
\ No newline at end of file
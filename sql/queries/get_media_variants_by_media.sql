@@ -0,0 +1,5 @@
+-- name: GetMediaVariantsByMedia :many
+SELECT *
+FROM media_variants
+WHERE media_id = ANY(sqlc.arg(media_ids)::uuid[])
+ORDER BY media_id, width;
\ No newline at end of file
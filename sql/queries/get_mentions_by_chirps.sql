@@ -0,0 +1,5 @@
+-- name: GetMentionsByChirps :many
+SELECT mentions.chirp_id, mentions.user_id, mentions.start_index, mentions.end_index
+FROM mentions
+WHERE mentions.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY mentions.start_index ASC;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: GetOAuthClientsByUser :many
+SELECT * FROM oauth_clients
+WHERE user_id = $1
+ORDER BY created_at;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetOAuthConsentsByUser :many
+SELECT oauth_consents.*, oauth_clients.name
+FROM oauth_consents
+JOIN oauth_clients ON oauth_clients.id = oauth_consents.client_id
+WHERE oauth_consents.user_id = $1
+ORDER BY oauth_consents.created_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: GetOAuthGrant :one
+SELECT * FROM oauth_grants
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: GetOAuthGrantByRefreshToken :one
+SELECT * FROM oauth_grants
+WHERE refresh_token_hash = $1;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- name: GetOAuthGrantsByUser :many
+SELECT oauth_grants.*, oauth_clients.name
+FROM oauth_grants
+JOIN oauth_clients ON oauth_clients.id = oauth_grants.client_id
+WHERE oauth_grants.user_id = $1
+ORDER BY oauth_grants.created_at ASC;
\ No newline at end of file
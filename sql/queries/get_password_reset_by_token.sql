@@ -0,0 +1,3 @@
+-- name: GetPasswordResetByToken :one
+SELECT * FROM password_resets
+WHERE token_hash = $1;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: GetPendingPreviewURLs :many
+SELECT DISTINCT chirps.preview_url::text AS url
+FROM chirps
+LEFT JOIN link_previews ON link_previews.url = chirps.preview_url
+WHERE chirps.preview_url IS NOT NULL
+    AND link_previews.url IS NULL
+    AND chirps.created_at >= sqlc.arg(since)
+LIMIT sqlc.arg('limit');
\ No newline at end of file
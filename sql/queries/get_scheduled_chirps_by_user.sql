@@ -0,0 +1,5 @@
+-- name: GetScheduledChirpsByUser :many
+SELECT *
+FROM chirps
+WHERE user_id = $1 AND status = 'scheduled'
+ORDER BY publish_at ASC;
\ No newline at end of file
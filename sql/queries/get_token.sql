@@ -1,2 +1,6 @@
 -- name: GetRefreshToken :one
-SELECT * FROM refresh_tokens WHERE token = $1 LIMIT 1;
\ No newline at end of file
+SELECT refresh_tokens.*
+FROM refresh_tokens
+JOIN users ON users.id = refresh_tokens.user_id
+WHERE refresh_tokens.token = $1 AND users.deleted_at IS NULL
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: GetTOTPCredential :one
+SELECT * FROM totp_credentials WHERE user_id = $1 LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- name: GetTrendingHashtags :many
+SELECT tag, current_count, previous_count
+FROM (
+    SELECT hashtags.tag,
+        coalesce(sum(hashtag_rollups.chirp_count) FILTER (WHERE hashtag_rollups.bucket >= sqlc.arg(window_start)), 0)::bigint AS current_count,
+        coalesce(sum(hashtag_rollups.chirp_count) FILTER (WHERE hashtag_rollups.bucket < sqlc.arg(window_start)), 0)::bigint AS previous_count
+    FROM hashtag_rollups
+    JOIN hashtags ON hashtags.id = hashtag_rollups.hashtag_id
+    WHERE hashtag_rollups.bucket >= sqlc.arg(previous_start)
+        AND hashtag_rollups.hashtag_id NOT IN (SELECT hashtag_id FROM hidden_hashtags)
+    GROUP BY hashtags.tag
+) AS counts
+WHERE current_count > 0
+ORDER BY (current_count + 1)::double precision / (previous_count + 1) DESC, current_count DESC, tag ASC
+LIMIT sqlc.arg('limit');
\ No newline at end of file
@@ -1,2 +1,2 @@
 -- name: GetUser :one
-SELECT * FROM users WHERE email = $1 LIMIT 1;
\ No newline at end of file
+SELECT * FROM users WHERE email = $1 AND deleted_at IS NULL LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: GetUserByHandle :one
+SELECT * FROM users WHERE lower(handle) = lower(sqlc.arg(handle)) AND deleted_at IS NULL LIMIT 1;
\ No newline at end of file
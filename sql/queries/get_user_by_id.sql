@@ -0,0 +1,2 @@
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1 AND deleted_at IS NULL LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- name: GetUserProfile :one
+SELECT users.*,
+    (SELECT count(*) FROM follows WHERE follows.followed_id = users.id) AS follower_count,
+    (SELECT count(*) FROM follows WHERE follows.follower_id = users.id) AS following_count,
+    (SELECT count(*) FROM chirps
+        WHERE chirps.user_id = users.id AND chirps.deleted_at IS NULL AND chirps.status = 'published') AS chirp_count
+FROM users
+WHERE users.id = $1 AND users.deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: GetUsersByHandles :many
+SELECT * FROM users WHERE lower(handle) = ANY(sqlc.arg(handles)::text[]) AND deleted_at IS NULL;
\ No newline at end of file
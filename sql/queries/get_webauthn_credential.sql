@@ -0,0 +1,2 @@
+-- name: GetWebAuthnCredential :one
+SELECT * FROM webauthn_credentials WHERE id = $1 LIMIT 1;
\ No newline at end of file
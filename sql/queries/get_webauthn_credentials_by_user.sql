@@ -0,0 +1,2 @@
+-- name: GetWebAuthnCredentialsByUser :many
+SELECT * FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at ASC;
\ No newline at end of file
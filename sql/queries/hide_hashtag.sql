@@ -0,0 +1,7 @@
+-- name: HideHashtag :exec
+INSERT INTO hidden_hashtags (hashtag_id, created_at)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (hashtag_id) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows WHERE follower_id = $1 AND followed_id = $2
+) AS following;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: LockFirstAdmin :exec
+SELECT pg_advisory_xact_lock(hashtext('promote_first_admin'));
\ No newline at end of file
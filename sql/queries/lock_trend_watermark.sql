@@ -0,0 +1,5 @@
+-- name: LockTrendWatermark :one
+SELECT processed_until
+FROM trend_watermarks
+WHERE name = $1
+FOR UPDATE SKIP LOCKED;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: PromoteFirstAdmin :execrows
+UPDATE users
+SET role = 'admin', updated_at = $2
+WHERE email = $1 AND deleted_at IS NULL
+AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin' AND deleted_at IS NULL);
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: PublishChirp :one
+UPDATE chirps
+SET status = 'published', published_at = sqlc.arg(published_at)::timestamp, updated_at = sqlc.arg(published_at)::timestamp
+WHERE id = sqlc.arg(id)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps AS refs
+        WHERE refs.in_reply_to = chirps.id OR refs.quote_of = chirps.id
+    );
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < $1;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: RecordTOTPFailure :exec
+UPDATE totp_credentials
+SET failed_attempts = CASE WHEN failed_attempts + 1 >= sqlc.arg(max_failures)::int THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= sqlc.arg(max_failures)::int THEN sqlc.arg(locked_until)::timestamp ELSE locked_until END
+WHERE user_id = sqlc.arg(user_id);
\ No newline at end of file
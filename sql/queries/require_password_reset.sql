@@ -0,0 +1,5 @@
+-- name: RequirePasswordReset :one
+UPDATE users
+SET password_reset_required = true, updated_at = $2
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
\ No newline at end of file
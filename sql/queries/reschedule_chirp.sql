@@ -0,0 +1,5 @@
+-- name: RescheduleChirp :one
+UPDATE chirps
+SET publish_at = $3, updated_at = $4
+WHERE id = $1 AND user_id = $2 AND status = 'scheduled'
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: ResetTOTPFailures :exec
+UPDATE totp_credentials
+SET failed_attempts = 0, locked_until = NULL
+WHERE user_id = $1;
\ No newline at end of file
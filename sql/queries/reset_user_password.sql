@@ -0,0 +1,4 @@
+-- name: ResetUserPassword :exec
+UPDATE users
+SET hashed_password = $2, password_reset_required = false, updated_at = $3
+WHERE id = $1 AND deleted_at IS NULL;
\ No newline at end of file
@@ -1,2 +1,9 @@
 -- name: ResetUsers :exec
-DELETE FROM users;
\ No newline at end of file
+WITH deleted_users AS (
+    UPDATE users
+    SET deleted_at = $1, updated_at = $1
+    WHERE deleted_at IS NULL
+)
+UPDATE chirps
+SET deleted_at = $1, updated_at = $1
+WHERE deleted_at IS NULL;
\ No newline at end of file
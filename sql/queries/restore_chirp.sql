@@ -0,0 +1,4 @@
+-- name: RestoreChirp :exec
+UPDATE chirps
+SET deleted_at = NULL, updated_at = sqlc.arg(updated_at)
+WHERE (id = sqlc.arg(id)::uuid OR rechirp_of = sqlc.arg(id)::uuid) AND deleted_at = sqlc.arg(deleted_at);
\ No newline at end of file
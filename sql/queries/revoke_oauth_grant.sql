@@ -0,0 +1,4 @@
+-- name: RevokeOAuthGrant :exec
+UPDATE oauth_grants
+SET revoked_at = $2, updated_at = $2
+WHERE id = $1 AND revoked_at IS NULL;
\ No newline at end of file
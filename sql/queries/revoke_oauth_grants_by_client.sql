@@ -0,0 +1,4 @@
+-- name: RevokeOAuthGrantsByClient :exec
+UPDATE oauth_grants
+SET revoked_at = $3, updated_at = $3
+WHERE user_id = $1 AND client_id = $2 AND revoked_at IS NULL;
\ No newline at end of file
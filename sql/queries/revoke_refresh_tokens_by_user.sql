@@ -0,0 +1,4 @@
+-- name: RevokeRefreshTokensByUser :exec
+UPDATE refresh_tokens
+SET revoked_at = $2, updated_at = $2
+WHERE user_id = $1 AND revoked_at IS NULL;
\ No newline at end of file
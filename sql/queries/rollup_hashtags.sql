@@ -0,0 +1,8 @@
+-- name: RollupHashtags :exec
+INSERT INTO hashtag_rollups (hashtag_id, bucket, chirp_count)
+SELECT hashtag_id, date_trunc('minute', created_at), count(DISTINCT chirp_id)
+FROM chirp_hashtags
+WHERE created_at > sqlc.arg(processed_from) AND created_at <= sqlc.arg(processed_until)
+GROUP BY hashtag_id, date_trunc('minute', created_at)
+ON CONFLICT (hashtag_id, bucket) DO UPDATE
+SET chirp_count = hashtag_rollups.chirp_count + EXCLUDED.chirp_count;
\ No newline at end of file
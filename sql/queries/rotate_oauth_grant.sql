@@ -0,0 +1,4 @@
+-- name: RotateOAuthGrant :execrows
+UPDATE oauth_grants
+SET refresh_token_hash = sqlc.arg(refresh_token_hash), updated_at = sqlc.arg(updated_at), expires_at = sqlc.arg(expires_at)
+WHERE id = sqlc.arg(id) AND refresh_token_hash = sqlc.arg(old_refresh_token_hash) AND revoked_at IS NULL;
\ No newline at end of file
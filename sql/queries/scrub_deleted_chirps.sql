@@ -0,0 +1,4 @@
+-- name: ScrubDeletedChirps :execrows
+UPDATE chirps
+SET body = '', preview_url = NULL
+WHERE deleted_at < $1 AND (body <> '' OR preview_url IS NOT NULL);
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    ts_headline('english',
+        replace(replace(replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+        to_tsquery('english', sqlc.arg(query)), 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') AS snippet,
+    ts_rank(search_vector, to_tsquery('english', sqlc.arg(query))) AS rank
+FROM chirps
+WHERE search_vector @@ to_tsquery('english', sqlc.arg(query))
+    AND deleted_at IS NULL AND status = 'published'
+    AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id))
+    AND (sqlc.narg(since)::timestamp IS NULL OR published_at >= sqlc.narg(since))
+    AND (sqlc.narg(until)::timestamp IS NULL OR published_at < sqlc.narg(until))
+ORDER BY
+    CASE WHEN sqlc.arg(by_rank)::boolean THEN ts_rank(search_vector, to_tsquery('english', sqlc.arg(query))) END DESC,
+    published_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- name: SearchUsers :many
+SELECT *
+FROM users
+WHERE deleted_at IS NULL
+    AND (sqlc.narg(query)::text IS NULL
+        OR email ILIKE '%' || sqlc.narg(query) || '%'
+        OR handle ILIKE '%' || sqlc.narg(query) || '%')
+    AND (sqlc.narg(role)::text IS NULL OR role = sqlc.narg(role))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
\ No newline at end of file
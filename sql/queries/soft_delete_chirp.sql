@@ -0,0 +1,4 @@
+-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = sqlc.arg(deleted_at), updated_at = sqlc.arg(deleted_at)
+WHERE (id = sqlc.arg(id)::uuid OR rechirp_of = sqlc.arg(id)::uuid) AND deleted_at IS NULL;
\ No newline at end of file
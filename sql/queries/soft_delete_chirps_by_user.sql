@@ -0,0 +1,8 @@
+-- name: SoftDeleteChirpsByUser :many
+UPDATE chirps
+SET deleted_at = sqlc.arg(deleted_at), updated_at = sqlc.arg(deleted_at)
+WHERE deleted_at IS NULL AND (
+    user_id = sqlc.arg(user_id)::uuid
+    OR rechirp_of IN (SELECT id FROM chirps AS own WHERE own.user_id = sqlc.arg(user_id)::uuid)
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: SoftDeleteUser :execrows
+UPDATE users
+SET deleted_at = $2, updated_at = $2
+WHERE id = $1 AND deleted_at IS NULL;
\ No newline at end of file
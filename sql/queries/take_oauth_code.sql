@@ -0,0 +1,4 @@
+-- name: TakeOAuthCode :one
+DELETE FROM oauth_codes
+WHERE code_hash = $1
+RETURNING *;
\ No newline at end of file
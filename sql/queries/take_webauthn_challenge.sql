@@ -0,0 +1,4 @@
+-- name: TakeWebAuthnChallenge :one
+DELETE FROM webauthn_challenges
+WHERE challenge = $1 AND ceremony = $2
+RETURNING *;
\ No newline at end of file
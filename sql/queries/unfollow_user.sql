@@ -0,0 +1,3 @@
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followed_id = $2;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: UnhideHashtag :execrows
+DELETE FROM hidden_hashtags
+WHERE hashtag_id IN (SELECT id FROM hashtags WHERE tag = $1);
\ No newline at end of file
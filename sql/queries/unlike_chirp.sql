@@ -0,0 +1,2 @@
+-- name: UnlikeChirp :execrows
+DELETE FROM likes WHERE user_id = $1 AND chirp_id = $2;
\ No newline at end of file
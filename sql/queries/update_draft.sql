@@ -0,0 +1,5 @@
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $3, in_reply_to = $4, quote_of = $5, media_ids = $6, publish_at = $7, updated_at = $8
+WHERE id = $1 AND user_id = $2
+RETURNING *;
\ No newline at end of file
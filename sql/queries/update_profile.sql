@@ -0,0 +1,5 @@
+-- name: UpdateProfile :one
+UPDATE users
+SET handle = $2, display_name = $3, bio = $4, avatar_media_id = $5, updated_at = $6
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
\ No newline at end of file
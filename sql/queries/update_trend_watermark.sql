@@ -0,0 +1,4 @@
+-- name: UpdateTrendWatermark :exec
+UPDATE trend_watermarks
+SET processed_until = $2
+WHERE name = $1;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: UpdateUserBan :one
+UPDATE users
+SET banned_at = $2, updated_at = $3
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, updated_at = $3
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
\ No newline at end of file
@@ -1,5 +1,5 @@
 -- name: UpdateUserRed :one
 UPDATE users
 SET is_chirpy_red = $2, updated_at = $3
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: UpdateWebAuthnSignCount :execrows
+UPDATE webauthn_credentials
+SET sign_count = sqlc.arg(sign_count), last_used_at = sqlc.arg(last_used_at)
+WHERE id = sqlc.arg(id) AND sign_count = sqlc.arg(old_sign_count);
\ No newline at end of file
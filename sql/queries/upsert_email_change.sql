@@ -0,0 +1,14 @@
+-- name: UpsertEmailChange :exec
+INSERT INTO email_changes (user_id, new_email, token_hash, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (user_id) DO UPDATE
+SET new_email = EXCLUDED.new_email,
+    token_hash = EXCLUDED.token_hash,
+    created_at = EXCLUDED.created_at,
+    expires_at = EXCLUDED.expires_at;
\ No newline at end of file
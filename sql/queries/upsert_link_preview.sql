@@ -0,0 +1,18 @@
+-- name: UpsertLinkPreview :exec
+INSERT INTO link_previews (url, fetched_at, ok, title, description, image_url, site_name)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (url) DO UPDATE
+SET fetched_at = EXCLUDED.fetched_at,
+    ok = EXCLUDED.ok,
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    image_url = EXCLUDED.image_url,
+    site_name = EXCLUDED.site_name;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: UpsertOAuthConsent :exec
+INSERT INTO oauth_consents (user_id, client_id, scopes, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $4
+)
+ON CONFLICT (user_id, client_id) DO UPDATE
+SET scopes = EXCLUDED.scopes, updated_at = EXCLUDED.updated_at;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- name: UpsertTOTPCredential :execrows
+INSERT INTO totp_credentials (user_id, secret, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_step = 0
+WHERE totp_credentials.enabled_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: UseMFAChallengeAttempt :execrows
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+    AND expires_at > sqlc.arg(now)::timestamp AND attempts < sqlc.arg(max_attempts)::int;
\ No newline at end of file
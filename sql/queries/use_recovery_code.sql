@@ -0,0 +1,4 @@
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = $3
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: UseTOTPStep :execrows
+UPDATE totp_credentials
+SET last_step = $2
+WHERE user_id = $1 AND last_step < $2;
\ No newline at end of file
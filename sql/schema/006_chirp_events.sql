@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE chirp_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    event_type TEXT NOT NULL,
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body TEXT NOT NULL
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION notify_chirp_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('chirp_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirp_events_notify
+AFTER INSERT ON chirp_events
+FOR EACH ROW EXECUTE FUNCTION notify_chirp_event();
+
+-- +goose Down
+DROP TRIGGER chirp_events_notify ON chirp_events;
+DROP FUNCTION notify_chirp_event();
+DROP TABLE chirp_events;
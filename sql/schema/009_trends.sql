@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE INDEX chirp_hashtags_created_at_idx ON chirp_hashtags (created_at);
+
+CREATE TABLE hashtag_rollups (
+    hashtag_id UUID NOT NULL REFERENCES hashtags (id) ON DELETE CASCADE,
+    bucket TIMESTAMP NOT NULL,
+    chirp_count INTEGER NOT NULL,
+    PRIMARY KEY (hashtag_id, bucket)
+);
+CREATE INDEX hashtag_rollups_bucket_idx ON hashtag_rollups (bucket);
+
+CREATE TABLE trend_watermarks (
+    name TEXT PRIMARY KEY,
+    processed_until TIMESTAMP NOT NULL
+);
+INSERT INTO trend_watermarks (name, processed_until) VALUES ('hashtag_rollups', '1970-01-01 00:00:00');
+
+CREATE TABLE hidden_hashtags (
+    hashtag_id UUID PRIMARY KEY REFERENCES hashtags (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE hidden_hashtags;
+DROP TABLE trend_watermarks;
+DROP TABLE hashtag_rollups;
+DROP INDEX chirp_hashtags_created_at_idx;
@@ -0,0 +1,35 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE likes (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX likes_chirp_id_idx ON likes (chirp_id);
+
+-- The counter is maintained in the same transaction as the like itself, so
+-- concurrent likes serialize on the chirp row and cascaded deletes are counted.
+-- +goose StatementBegin
+CREATE FUNCTION update_chirp_like_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+    ELSE
+        UPDATE chirps SET like_count = like_count - 1 WHERE id = OLD.chirp_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER likes_count
+AFTER INSERT OR DELETE ON likes
+FOR EACH ROW EXECUTE FUNCTION update_chirp_like_count();
+
+-- +goose Down
+DROP TRIGGER likes_count ON likes;
+DROP FUNCTION update_chirp_like_count();
+DROP TABLE likes;
+ALTER TABLE chirps DROP COLUMN like_count;
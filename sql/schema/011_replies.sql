@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE chirps
+    ADD COLUMN in_reply_to UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    ADD COLUMN root_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_in_reply_to_idx ON chirps (in_reply_to);
+CREATE INDEX chirps_root_id_idx ON chirps (root_id);
+
+-- +goose Down
+DROP INDEX chirps_root_id_idx;
+DROP INDEX chirps_in_reply_to_idx;
+ALTER TABLE chirps
+    DROP COLUMN deleted_at,
+    DROP COLUMN root_id,
+    DROP COLUMN in_reply_to;
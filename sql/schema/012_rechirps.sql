@@ -0,0 +1,38 @@
+-- +goose Up
+ALTER TABLE chirps
+    ADD COLUMN rechirp_of UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    ADD COLUMN quote_of UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    ADD COLUMN rechirp_count INTEGER NOT NULL DEFAULT 0;
+
+-- A user can rechirp a chirp only once.
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_idx ON chirps (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL;
+CREATE INDEX chirps_rechirp_of_idx ON chirps (rechirp_of);
+CREATE INDEX chirps_quote_of_idx ON chirps (quote_of);
+
+-- +goose StatementBegin
+CREATE FUNCTION update_chirp_rechirp_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' AND NEW.rechirp_of IS NOT NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count + 1 WHERE id = NEW.rechirp_of;
+    ELSIF TG_OP = 'DELETE' AND OLD.rechirp_of IS NOT NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count - 1 WHERE id = OLD.rechirp_of;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_rechirp_count
+AFTER INSERT OR DELETE ON chirps
+FOR EACH ROW EXECUTE FUNCTION update_chirp_rechirp_count();
+
+-- +goose Down
+DROP TRIGGER chirps_rechirp_count ON chirps;
+DROP FUNCTION update_chirp_rechirp_count();
+DROP INDEX chirps_quote_of_idx;
+DROP INDEX chirps_rechirp_of_idx;
+DROP INDEX chirps_user_id_rechirp_of_idx;
+ALTER TABLE chirps
+    DROP COLUMN rechirp_count,
+    DROP COLUMN quote_of,
+    DROP COLUMN rechirp_of;
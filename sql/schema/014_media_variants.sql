@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE media_files ADD COLUMN blurhash TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE media_variants (
+    media_id UUID NOT NULL REFERENCES media_files (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    storage_key TEXT NOT NULL,
+    PRIMARY KEY (media_id, name)
+);
+
+-- +goose Down
+DROP TABLE media_variants;
+ALTER TABLE media_files DROP COLUMN blurhash;
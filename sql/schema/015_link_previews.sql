@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN preview_url TEXT;
+
+-- Failed fetches are kept too, so broken links are not retried for every
+-- chirp that contains them.
+CREATE TABLE link_previews (
+    url TEXT PRIMARY KEY,
+    fetched_at TIMESTAMP NOT NULL,
+    ok BOOLEAN NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    image_url TEXT NOT NULL,
+    site_name TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE link_previews;
+ALTER TABLE chirps DROP COLUMN preview_url;
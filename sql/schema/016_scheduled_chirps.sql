@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'published',
+    ADD COLUMN publish_at TIMESTAMP;
+
+CREATE INDEX chirps_scheduled_publish_at_idx ON chirps (publish_at) WHERE status = 'scheduled';
+
+-- +goose Down
+DROP INDEX chirps_scheduled_publish_at_idx;
+ALTER TABLE chirps
+    DROP COLUMN publish_at,
+    DROP COLUMN status;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE exports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    storage_key TEXT,
+    size_bytes BIGINT
+);
+
+CREATE INDEX exports_user_id_idx ON exports (user_id, created_at);
+-- Each user has at most one export in progress.
+CREATE UNIQUE INDEX exports_active_user_id_idx ON exports (user_id) WHERE status IN ('pending', 'running');
+
+-- +goose Down
+DROP TABLE exports;
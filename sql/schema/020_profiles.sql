@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN handle TEXT,
+    ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+    ADD COLUMN avatar_media_id UUID REFERENCES media_files (id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX users_handle_key ON users (lower(handle));
+
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followed_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followed_id),
+    CHECK (follower_id <> followed_id)
+);
+CREATE INDEX follows_followed_id_idx ON follows (followed_id);
+
+-- +goose Down
+DROP TABLE follows;
+DROP INDEX users_handle_key;
+ALTER TABLE users
+    DROP COLUMN avatar_media_id,
+    DROP COLUMN bio,
+    DROP COLUMN display_name,
+    DROP COLUMN handle;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE email_changes (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    new_email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE email_changes;
@@ -0,0 +1,54 @@
+-- +goose Up
+CREATE TABLE oauth_clients (
+    id TEXT PRIMARY KEY,
+    -- NULL for public clients, which cannot keep a secret and rely on PKCE.
+    secret_hash TEXT,
+    name TEXT NOT NULL,
+    redirect_uris TEXT[] NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX oauth_clients_user_id_idx ON oauth_clients (user_id);
+
+-- The scopes each user has allowed each client.
+CREATE TABLE oauth_consents (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    client_id TEXT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, client_id)
+);
+
+-- Authorization codes are single use: redeeming one deletes its row.
+CREATE TABLE oauth_codes (
+    code_hash TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    code_challenge TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- A grant backs one refresh token and the access tokens issued from it,
+-- so revoking the grant revokes them all.
+CREATE TABLE oauth_grants (
+    id UUID PRIMARY KEY,
+    refresh_token_hash TEXT NOT NULL UNIQUE,
+    client_id TEXT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+CREATE INDEX oauth_grants_user_id_client_id_idx ON oauth_grants (user_id, client_id);
+
+-- +goose Down
+DROP TABLE oauth_grants;
+DROP TABLE oauth_codes;
+DROP TABLE oauth_consents;
+DROP TABLE oauth_clients;
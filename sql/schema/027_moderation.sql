@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE users
+    ADD COLUMN suspended_until TIMESTAMP,
+    ADD COLUMN banned_at TIMESTAMP,
+    ADD COLUMN password_reset_required BOOLEAN NOT NULL DEFAULT false;
+
+-- Password resets forced by an admin, completed with the mailed token.
+CREATE TABLE password_resets (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Entries outlive the users involved, so references are cleared rather
+-- than cascaded.
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY,
+    actor_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    target_user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+CREATE INDEX audit_log_actor_id_idx ON audit_log (actor_id);
+CREATE INDEX audit_log_target_user_id_idx ON audit_log (target_user_id);
+
+-- +goose Down
+DROP TABLE audit_log;
+DROP TABLE password_resets;
+ALTER TABLE users
+    DROP COLUMN password_reset_required,
+    DROP COLUMN banned_at,
+    DROP COLUMN suspended_until;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX chirp_events_created_at_idx ON chirp_events (created_at);
+
+-- +goose Down
+DROP INDEX chirp_events_created_at_idx;
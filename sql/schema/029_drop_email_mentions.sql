@@ -0,0 +1,8 @@
+-- +goose Up
+-- Mentions written as @email revealed which addresses have accounts.
+DELETE FROM mentions
+USING chirps
+WHERE chirps.id = mentions.chirp_id
+    AND position('@' IN substr(chirps.body, mentions.start_index + 2, mentions.end_index - mentions.start_index - 1)) > 0;
+
+-- +goose Down
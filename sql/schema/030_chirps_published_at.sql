@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN published_at TIMESTAMP;
+UPDATE chirps SET published_at = created_at WHERE status = 'published';
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN published_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Users in the trash no longer hold on to their email address or handle.
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_email_key ON users (email) WHERE deleted_at IS NULL;
+DROP INDEX users_handle_key;
+CREATE UNIQUE INDEX users_handle_key ON users (lower(handle)) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX users_handle_key;
+CREATE UNIQUE INDEX users_handle_key ON users (lower(handle));
+DROP INDEX users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
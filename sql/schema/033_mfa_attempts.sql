@@ -0,0 +1,22 @@
+-- +goose Up
+-- An MFA token names its challenge row, which counts wrong codes and is
+-- deleted once a code is accepted.
+CREATE TABLE mfa_challenges (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Wrong codes across all challenges; enough of them lock the second
+-- factor for a while.
+ALTER TABLE totp_credentials
+    ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE totp_credentials
+    DROP COLUMN locked_until,
+    DROP COLUMN failed_attempts;
+DROP TABLE mfa_challenges;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- New challenges are refused once too many of a ceremony are pending.
+CREATE INDEX webauthn_challenges_ceremony_expires_at_idx ON webauthn_challenges (ceremony, expires_at);
+
+-- +goose Down
+DROP INDEX webauthn_challenges_ceremony_expires_at_idx;
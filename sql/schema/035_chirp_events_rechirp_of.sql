@@ -0,0 +1,6 @@
+-- +goose Up
+-- Rechirps have no body of their own; events carry the chirp they repeat.
+ALTER TABLE chirp_events ADD COLUMN rechirp_of UUID;
+
+-- +goose Down
+ALTER TABLE chirp_events DROP COLUMN rechirp_of;
@@ -0,0 +1,53 @@
+-- +goose Up
+-- Rechirps in the trash, such as those of deleted users, are not counted.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_chirp_rechirp_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' AND NEW.rechirp_of IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count + 1 WHERE id = NEW.rechirp_of;
+    ELSIF TG_OP = 'DELETE' AND OLD.rechirp_of IS NOT NULL AND OLD.deleted_at IS NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count - 1 WHERE id = OLD.rechirp_of;
+    ELSIF TG_OP = 'UPDATE' AND NEW.rechirp_of IS NOT NULL THEN
+        IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+            UPDATE chirps SET rechirp_count = rechirp_count - 1 WHERE id = NEW.rechirp_of;
+        ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+            UPDATE chirps SET rechirp_count = rechirp_count + 1 WHERE id = NEW.rechirp_of;
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER chirps_rechirp_count ON chirps;
+CREATE TRIGGER chirps_rechirp_count
+AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON chirps
+FOR EACH ROW EXECUTE FUNCTION update_chirp_rechirp_count();
+
+UPDATE chirps SET rechirp_count = (
+    SELECT count(*) FROM chirps AS rechirps
+    WHERE rechirps.rechirp_of = chirps.id AND rechirps.deleted_at IS NULL
+);
+
+-- +goose Down
+DROP TRIGGER chirps_rechirp_count ON chirps;
+CREATE TRIGGER chirps_rechirp_count
+AFTER INSERT OR DELETE ON chirps
+FOR EACH ROW EXECUTE FUNCTION update_chirp_rechirp_count();
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_chirp_rechirp_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' AND NEW.rechirp_of IS NOT NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count + 1 WHERE id = NEW.rechirp_of;
+    ELSIF TG_OP = 'DELETE' AND OLD.rechirp_of IS NOT NULL THEN
+        UPDATE chirps SET rechirp_count = rechirp_count - 1 WHERE id = OLD.rechirp_of;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+UPDATE chirps SET rechirp_count = (
+    SELECT count(*) FROM chirps AS rechirps WHERE rechirps.rechirp_of = chirps.id
+);